@@ -0,0 +1,144 @@
+// Package access decides who is allowed to sign in
+package access
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k8s-community/ui/models"
+	"github.com/k8s-community/ui/providers"
+)
+
+// defaultHosts are the hosts of the sources which are recorded without the host
+var defaultHosts = map[string]string{
+	models.SourceGitHub: "github.com",
+	models.SourceGitLab: "gitlab.com",
+}
+
+// Rules restrict sign in. The user is allowed if the login isn't blocked and
+// either there are no allowing rules or the login is allowed or the user is a member of the allowed groups.
+type Rules struct {
+	// Allow and Block contain the logins as "source/login", see ParseLogin
+	Allow []string
+	Block []string
+
+	// Groups contain the groups as "source/group", see ValidGroup
+	Groups []string
+}
+
+// Denied is the reason of the rejected sign in
+type Denied struct {
+	Reason string
+}
+
+func (d *Denied) Error() string {
+	return "access denied: " + d.Reason
+}
+
+// Restricted tells if not everyone is allowed to sign in
+func (r Rules) Restricted() bool {
+	return len(r.Allow) > 0 || len(r.Groups) > 0
+}
+
+// Check returns *Denied error if the user authorized by the provider isn't allowed to sign in
+// The source is models.User.Source of the provider's users.
+func (r Rules) Check(source string, identity *providers.Identity) error {
+	if match(r.Block, source, identity.Login) {
+		return &Denied{Reason: fmt.Sprintf("login %s is blocked", identity.Login)}
+	}
+
+	if !r.Restricted() || match(r.Allow, source, identity.Login) {
+		return nil
+	}
+
+	for _, group := range identity.Groups {
+		if matchGroup(r.Groups, source, group) {
+			return nil
+		}
+	}
+
+	return &Denied{Reason: fmt.Sprintf("login %s is not allowed and is not a member of the allowed groups", identity.Login)}
+}
+
+// ParseLogin splits the entry of the lists "source/login" into the source (models.User.Source) and the login,
+// e.g. "github/alice", "github:github.example.com/alice" or "oidc:keycloak.example.com/auth/realms/workshop/bob".
+// The entries without the source are rejected since the same login may belong to different people on different providers.
+func ParseLogin(entry string) (source, login string, err error) {
+	i := strings.LastIndex(entry, "/")
+	if i <= 0 || i == len(entry)-1 {
+		return "", "", fmt.Errorf("%q should be source/login, e.g. github/alice or gitlab:gitlab.example.com/bob", entry)
+	}
+
+	return entry[:i], entry[i+1:], nil
+}
+
+// ValidGroup checks the entry of the groups list "source/group": GitHub organizations "github/org",
+// teams "github/org/team" (or "github:github.example.com/org/team"), OpenID Connect groups
+// "oidc:keycloak.example.com/auth/realms/workshop/group". The entries without the source are rejected
+// since the groups of different providers may have the same names.
+func ValidGroup(entry string) error {
+	if i := strings.Index(entry, "/"); i > 0 && i < len(entry)-1 {
+		switch strings.SplitN(strings.ToLower(entry[:i]), ":", 2)[0] {
+		case models.SourceGitHub, models.SourceGitLab, models.SourceOIDC:
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q should be source/group, e.g. github/k8s-community or github/k8s-community/workshop", entry)
+}
+
+// matchGroup tells if the list contains the group of the source, the groups are case-insensitive
+func matchGroup(list []string, source, group string) bool {
+	qualified := canonicalSource(source) + "/" + strings.ToLower(group)
+	for _, item := range list {
+		if canonicalGroup(item) == qualified {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canonicalGroup returns the entry of the groups list with the host of the source, e.g. "github:github.com/org"
+func canonicalGroup(entry string) string {
+	entry = strings.ToLower(entry)
+	for source, host := range defaultHosts {
+		if strings.HasPrefix(entry, source+"/") {
+			return source + ":" + host + entry[len(source):]
+		}
+	}
+
+	return entry
+}
+
+// match tells if the list contains the login of the source, the logins are case-insensitive as GitHub ones
+func match(list []string, source, login string) bool {
+	for _, item := range list {
+		itemSource, itemLogin, err := ParseLogin(item)
+		if err != nil {
+			// The invalid entries are reported on startup, they never match
+			continue
+		}
+
+		if sameSource(itemSource, source) && strings.EqualFold(itemLogin, login) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameSource tells if the sources are the same, e.g. "github" and "github:github.com"
+func sameSource(a, b string) bool {
+	return canonicalSource(a) == canonicalSource(b)
+}
+
+// canonicalSource returns the source with the host
+func canonicalSource(source string) string {
+	source = strings.ToLower(source)
+	if host, ok := defaultHosts[source]; ok {
+		return source + ":" + host
+	}
+
+	return source
+}
@@ -0,0 +1,154 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/k8s-community/ui/providers"
+)
+
+func TestParseLogin(t *testing.T) {
+	tests := []struct {
+		entry  string
+		source string
+		login  string
+		valid  bool
+	}{
+		{entry: "github/alice", source: "github", login: "alice", valid: true},
+		{entry: "github:github.example.com/alice", source: "github:github.example.com", login: "alice", valid: true},
+		{
+			entry:  "oidc:keycloak.example.com/auth/realms/workshop/bob",
+			source: "oidc:keycloak.example.com/auth/realms/workshop",
+			login:  "bob",
+			valid:  true,
+		},
+		{entry: "alice"},
+		{entry: "/alice"},
+		{entry: "github/"},
+		{entry: ""},
+	}
+
+	for _, test := range tests {
+		source, login, err := ParseLogin(test.entry)
+		if (err == nil) != test.valid {
+			t.Errorf("ParseLogin(%q) error = %v, want valid %v", test.entry, err, test.valid)
+			continue
+		}
+		if source != test.source || login != test.login {
+			t.Errorf("ParseLogin(%q) = %q, %q, want %q, %q", test.entry, source, login, test.source, test.login)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	list := []string{
+		"github/alice",
+		"github:github.example.com/bob",
+		"gitlab:gitlab.com/carol",
+		"oidc:keycloak.example.com/auth/realms/workshop/dave",
+		"mallory", // bare logins never match
+	}
+
+	tests := []struct {
+		source string
+		login  string
+		match  bool
+	}{
+		{source: "github", login: "alice", match: true},
+		{source: "github", login: "ALICE", match: true},
+		{source: "github:github.com", login: "alice", match: true},
+		{source: "github:github.example.com", login: "alice"},
+		{source: "gitlab", login: "alice"},
+		{source: "oidc:keycloak.example.com/auth/realms/workshop", login: "alice"},
+
+		{source: "github:github.example.com", login: "bob", match: true},
+		{source: "github", login: "bob"},
+		{source: "github:github.other.com", login: "bob"},
+
+		{source: "gitlab", login: "carol", match: true},
+		{source: "gitlab:gitlab.example.com", login: "carol"},
+
+		{source: "oidc:keycloak.example.com/auth/realms/workshop", login: "dave", match: true},
+		{source: "oidc:keycloak.example.com/auth/realms/other", login: "dave"},
+
+		{source: "github", login: "mallory"},
+	}
+
+	for _, test := range tests {
+		if got := match(list, test.source, test.login); got != test.match {
+			t.Errorf("match(%s, %s) = %v, want %v", test.source, test.login, got, test.match)
+		}
+	}
+}
+
+func TestValidGroup(t *testing.T) {
+	valid := []string{
+		"github/k8s-community",
+		"github/k8s-community/workshop",
+		"github:github.example.com/k8s-community/workshop",
+		"gitlab/k8s-community",
+		"oidc:keycloak.example.com/auth/realms/workshop/students",
+	}
+	for _, entry := range valid {
+		if err := ValidGroup(entry); err != nil {
+			t.Errorf("ValidGroup(%q) = %v, want nil", entry, err)
+		}
+	}
+
+	invalid := []string{"k8s-community", "k8s-community/workshop", "github/", "/workshop", ""}
+	for _, entry := range invalid {
+		if err := ValidGroup(entry); err == nil {
+			t.Errorf("ValidGroup(%q) = nil, want error", entry)
+		}
+	}
+}
+
+func TestRulesCheck(t *testing.T) {
+	rules := Rules{
+		Allow:  []string{"github/alice", "github/mallory"},
+		Block:  []string{"github/mallory"},
+		Groups: []string{"github/k8s-community/workshop", "oidc:keycloak.example.com/auth/realms/workshop/students"},
+	}
+	keycloak := "oidc:keycloak.example.com/auth/realms/workshop"
+
+	tests := []struct {
+		source   string
+		identity providers.Identity
+		allowed  bool
+	}{
+		{source: "github", identity: providers.Identity{Login: "alice"}, allowed: true},
+		{source: "gitlab", identity: providers.Identity{Login: "alice"}},
+		{source: "github:github.example.com", identity: providers.Identity{Login: "alice"}},
+		{source: "github", identity: providers.Identity{Login: "mallory"}},
+		{
+			source:   "github",
+			identity: providers.Identity{Login: "bob", Groups: []string{"K8S-community/Workshop"}},
+			allowed:  true,
+		},
+		{
+			source:   "github:github.com",
+			identity: providers.Identity{Login: "bob", Groups: []string{"k8s-community/workshop"}},
+			allowed:  true,
+		},
+		{source: "github", identity: providers.Identity{Login: "bob", Groups: []string{"k8s-community"}}},
+		{source: "github:github.example.com", identity: providers.Identity{Login: "bob", Groups: []string{"k8s-community/workshop"}}},
+		// The groups named like the allowed GitHub team don't admit the users of other providers
+		{source: keycloak, identity: providers.Identity{Login: "eve", Groups: []string{"k8s-community/workshop"}}},
+		{source: "gitlab", identity: providers.Identity{Login: "eve", Groups: []string{"k8s-community/workshop"}}},
+		{source: keycloak, identity: providers.Identity{Login: "carol", Groups: []string{"students"}}, allowed: true},
+		{source: "oidc:keycloak.example.com/auth/realms/other", identity: providers.Identity{Login: "eve", Groups: []string{"students"}}},
+	}
+
+	for _, test := range tests {
+		err := rules.Check(test.source, &test.identity)
+		if (err == nil) != test.allowed {
+			t.Errorf("Check(%s, %s) = %v, want allowed %v", test.source, test.identity.Login, err, test.allowed)
+		}
+		if _, ok := err.(*Denied); err != nil && !ok {
+			t.Errorf("Check(%s, %s) returned %T, want *Denied", test.source, test.identity.Login, err)
+		}
+	}
+
+	if err := (Rules{}).Check("gitlab", &providers.Identity{Login: "anyone"}); err != nil {
+		t.Errorf("Check without rules = %v, want nil", err)
+	}
+}
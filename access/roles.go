@@ -0,0 +1,76 @@
+package access
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k8s-community/ui/models"
+)
+
+// Roles of the users
+const (
+	RoleParticipant = "participant"
+	RoleInstructor  = "instructor"
+	RoleAdmin       = "admin"
+)
+
+// Roles assign the roles to the users, the users who aren't listed are participants
+type Roles struct {
+	// Admins and Instructors contain the logins as "source/login", see ParseLogin
+	Admins      []string
+	Instructors []string
+}
+
+// Role returns the role of the user specified by the source (models.User.Source) and the login
+func (r Roles) Role(source, login string) string {
+	switch {
+	case match(r.Admins, source, login):
+		return RoleAdmin
+	case match(r.Instructors, source, login):
+		return RoleInstructor
+	default:
+		return RoleParticipant
+	}
+}
+
+// Builds decide who can see the build results
+type Builds struct {
+	Roles Roles
+
+	// Public contains the repositories which builds are shown to everyone as "username/repository",
+	// see ValidRepository
+	Public []string
+}
+
+// ValidRepository checks the entry of the public repositories list "username/repository".
+// The bare repository names are rejected since the repositories of any user may have them.
+func ValidRepository(entry string) error {
+	parts := strings.Split(entry, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("%q should be username/repository, e.g. k8s-community/myapp", entry)
+	}
+
+	return nil
+}
+
+// CanView tells if the user specified by the source and the login (both are empty for the guests)
+// can see the build of the repository owned by the GitHub user.
+// The owners see their builds, the instructors and the admins see all the builds.
+func (b Builds) CanView(source, login, owner, repository string) bool {
+	for _, public := range b.Public {
+		if strings.EqualFold(public, owner+"/"+repository) {
+			return true
+		}
+	}
+
+	if login == "" {
+		return false
+	}
+
+	// github-integration builds the repositories of github.com users only
+	if source == models.SourceGitHub && strings.EqualFold(login, owner) {
+		return true
+	}
+
+	return b.Roles.Role(source, login) != RoleParticipant
+}
@@ -0,0 +1,77 @@
+package access
+
+import "testing"
+
+func TestRolesRole(t *testing.T) {
+	roles := Roles{
+		Admins:      []string{"github/alice", "alice"},
+		Instructors: []string{"github:github.example.com/bob", "oidc:keycloak.example.com/auth/realms/workshop/carol"},
+	}
+
+	tests := []struct {
+		source string
+		login  string
+		role   string
+	}{
+		{source: "github", login: "alice", role: RoleAdmin},
+		{source: "github:github.com", login: "Alice", role: RoleAdmin},
+		{source: "gitlab", login: "alice", role: RoleParticipant},
+		{source: "github:github.example.com", login: "alice", role: RoleParticipant},
+		{source: "oidc:evil.example.com", login: "alice", role: RoleParticipant},
+
+		{source: "github:github.example.com", login: "bob", role: RoleInstructor},
+		{source: "github", login: "bob", role: RoleParticipant},
+
+		{source: "oidc:keycloak.example.com/auth/realms/workshop", login: "carol", role: RoleInstructor},
+		{source: "oidc:keycloak.example.com", login: "carol", role: RoleParticipant},
+	}
+
+	for _, test := range tests {
+		if got := roles.Role(test.source, test.login); got != test.role {
+			t.Errorf("Role(%s, %s) = %s, want %s", test.source, test.login, got, test.role)
+		}
+	}
+}
+
+func TestValidRepository(t *testing.T) {
+	for entry, valid := range map[string]bool{
+		"k8s-community/myapp": true,
+		"myapp":               false,
+		"/myapp":              false,
+		"k8s-community/":      false,
+		"k8s-community/a/b":   false,
+	} {
+		if err := ValidRepository(entry); (err == nil) != valid {
+			t.Errorf("ValidRepository(%q) = %v, want valid %v", entry, err, valid)
+		}
+	}
+}
+
+func TestBuildsCanView(t *testing.T) {
+	rules := Builds{
+		Roles:  Roles{Instructors: []string{"github/bob"}},
+		Public: []string{"k8s-community/myapp"},
+	}
+
+	tests := []struct {
+		source, login, owner, repository string
+		canView                          bool
+	}{
+		{owner: "k8s-community", repository: "myapp", canView: true},
+		{owner: "K8S-Community", repository: "MyApp", canView: true},
+		{owner: "alice", repository: "myapp"},
+		{owner: "alice", repository: "app"},
+		{source: "github", login: "alice", owner: "alice", repository: "app", canView: true},
+		{source: "gitlab", login: "alice", owner: "alice", repository: "app"},
+		{source: "github", login: "bob", owner: "alice", repository: "app", canView: true},
+		{source: "gitlab", login: "bob", owner: "alice", repository: "app"},
+	}
+
+	for _, test := range tests {
+		got := rules.CanView(test.source, test.login, test.owner, test.repository)
+		if got != test.canView {
+			t.Errorf("CanView(%s, %s, %s/%s) = %v, want %v",
+				test.source, test.login, test.owner, test.repository, got, test.canView)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+// Package buildlog parses the output of the builds to show it on the pages.
+// The text is kept as is (it's escaped by html/template), ANSI SGR sequences are converted
+// to the styles of the spans and the other control sequences are dropped.
+package buildlog
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FoldLines is the number of lines of a section after which the section is folded
+const FoldLines = 30
+
+// foldMarker matches the markers of the sections printed by Travis CI and GitHub Actions compatible scripts
+var foldMarker = regexp.MustCompile(`^(?:travis_fold:(start|end):(\S+)|::(group|endgroup)::(.*))$`)
+
+// Span is a piece of the line with the same style
+type Span struct {
+	Text  string
+	Class string // CSS classes of the style, e.g. "ansi-bold ansi-fg-1"
+}
+
+// Line is a line of the log
+type Line struct {
+	Number  int
+	Section string // name of the section the line belongs to, empty if it's out of sections
+	Spans   []Span
+}
+
+// Section is a group of the lines which is folded if it's long
+type Section struct {
+	Name  string // empty for the lines out of sections, they are never folded
+	Lines []*Line
+}
+
+// Folded tells if the section should be shown collapsed
+func (s *Section) Folded() bool {
+	return s.Name != "" && len(s.Lines) > FoldLines
+}
+
+// Parser converts the log into the lines. The log can be written by parts,
+// the style and the section are kept between them.
+type Parser struct {
+	style   style
+	number  int
+	section string
+	partial string // the last line which isn't finished yet
+}
+
+// Parse parses the whole log
+func Parse(log string) []*Line {
+	p := &Parser{}
+	return append(p.Write(log), p.Flush()...)
+}
+
+// Write parses the next part of the log and returns the finished lines
+func (p *Parser) Write(text string) []*Line {
+	text = p.partial + text
+	p.partial = ""
+
+	var lines []*Line
+	for {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			break
+		}
+		if line := p.line(strings.TrimSuffix(text[:i], "\r")); line != nil {
+			lines = append(lines, line)
+		}
+		text = text[i+1:]
+	}
+	p.partial = text
+
+	return lines
+}
+
+// Flush returns the unfinished last line, it should be called when the log is complete
+func (p *Parser) Flush() []*Line {
+	text := p.partial
+	p.partial = ""
+	if text == "" {
+		return nil
+	}
+
+	if line := p.line(text); line != nil {
+		return []*Line{line}
+	}
+
+	return nil
+}
+
+// Partial returns the unfinished last line without consuming it, nil if there is no such a line.
+// It's used to show the line while the log is still written.
+func (p *Parser) Partial() *Line {
+	if p.partial == "" {
+		return nil
+	}
+
+	parser := *p
+	return parser.line(p.partial)
+}
+
+// Lines returns the number of the lines parsed so far
+func (p *Parser) Lines() int {
+	return p.number
+}
+
+// line parses a line without the line break, nil is returned for the lines with fold markers only
+func (p *Parser) line(text string) *Line {
+	var spans []Span
+	marker := false
+
+	// Carriage return overwrites the line in the terminal (e.g. progress bars), so only the text
+	// after the last one is shown, but the styles of the overwritten parts are still applied
+	for i, segment := range strings.Split(text, "\r") {
+		segmentSpans := p.spans(segment)
+		if p.fold(segmentSpans) {
+			marker = true
+			continue
+		}
+		if i > 0 && len(segmentSpans) == 0 {
+			continue
+		}
+		spans = segmentSpans
+	}
+
+	if marker && len(spans) == 0 {
+		return nil
+	}
+
+	p.number++
+	return &Line{Number: p.number, Section: p.section, Spans: spans}
+}
+
+// fold tells if the spans are a fold marker and opens or closes the section
+func (p *Parser) fold(spans []Span) bool {
+	var text string
+	for _, span := range spans {
+		text += span.Text
+	}
+
+	m := foldMarker.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return false
+	}
+
+	switch {
+	case m[1] == "start":
+		p.section = m[2]
+	case m[3] == "group":
+		p.section = strings.TrimSpace(m[4])
+		if p.section == "" {
+			p.section = "group"
+		}
+	default:
+		p.section = ""
+	}
+
+	return true
+}
+
+// spans splits the text into the spans by the escape sequences
+func (p *Parser) spans(text string) []Span {
+	var spans []Span
+	var b bytes.Buffer
+
+	add := func() {
+		if b.Len() == 0 {
+			return
+		}
+		spans = append(spans, Span{Text: b.String(), Class: p.style.class()})
+		b.Reset()
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\x1b':
+			if i+1 < len(text) && text[i+1] == ']' {
+				// OSC sequence (e.g. the window title or a hyperlink) is terminated by BEL or ESC \
+				j := i + 2
+				for ; j < len(text); j++ {
+					if text[j] == '\a' {
+						break
+					}
+					if text[j] == '\x1b' && j+1 < len(text) && text[j+1] == '\\' {
+						j++
+						break
+					}
+				}
+				i = j
+				continue
+			}
+			if i+1 >= len(text) || text[i+1] != '[' {
+				// Other escape sequences (e.g. ESC ( B selecting the character set) have
+				// intermediate bytes followed by the final byte: drop all of them
+				j := i + 1
+				for j < len(text) && text[j] >= 0x20 && text[j] <= 0x2f {
+					j++
+				}
+				i = j
+				continue
+			}
+
+			// CSI sequence: parameters and intermediate bytes are followed by the final byte
+			j := i + 2
+			for j < len(text) && (text[j] < 0x40 || text[j] > 0x7e) {
+				j++
+			}
+			if j >= len(text) {
+				i = j
+				continue
+			}
+
+			if text[j] == 'm' {
+				add()
+				p.style.apply(text[i+2 : j])
+			}
+			i = j
+		case c == '\t' || c >= 0x20 && c != 0x7f:
+			b.WriteByte(c)
+		}
+	}
+	add()
+
+	return spans
+}
+
+// Sections groups the lines by the sections
+func Sections(lines []*Line) []*Section {
+	var sections []*Section
+	var current *Section
+
+	for _, line := range lines {
+		if current == nil || current.Name != line.Section {
+			current = &Section{Name: line.Section}
+			sections = append(sections, current)
+		}
+		current.Lines = append(current.Lines, line)
+	}
+
+	return sections
+}
+
+// style is the state of SGR attributes, the colors are indexes of 16 color palette or -1 for the default
+type style struct {
+	bold, faint, italic, underline bool
+	fg, bg                         int
+	set                            bool // the colors are initialized
+}
+
+// apply applies SGR parameters, e.g. "1;31"
+func (s *style) apply(params string) {
+	if !s.set {
+		s.reset()
+	}
+
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			if codes[i] != "" {
+				continue
+			}
+			// ESC[m is the same as ESC[0m
+			code = 0
+		}
+
+		switch {
+		case code == 0:
+			s.reset()
+		case code == 1:
+			s.bold = true
+		case code == 2:
+			s.faint = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 22:
+			s.bold, s.faint = false, false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case code >= 30 && code <= 37:
+			s.fg = code - 30
+		case code == 39:
+			s.fg = -1
+		case code >= 40 && code <= 47:
+			s.bg = code - 40
+		case code == 49:
+			s.bg = -1
+		case code >= 90 && code <= 97:
+			s.fg = code - 90 + 8
+		case code >= 100 && code <= 107:
+			s.bg = code - 100 + 8
+		case code == 38 || code == 48:
+			// Extended colors: 5;n is 256 color palette, 2;r;g;b is true color.
+			// Only the first 16 colors of the palette are supported, the others are shown by default.
+			color := -1
+			if i+2 < len(codes) && codes[i+1] == "5" {
+				if n, err := strconv.Atoi(codes[i+2]); err == nil && n >= 0 && n < 16 {
+					color = n
+				}
+				i += 2
+			} else if i+1 < len(codes) && codes[i+1] == "2" {
+				i += 4
+			}
+			if code == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+}
+
+// reset resets all the attributes
+func (s *style) reset() {
+	*s = style{fg: -1, bg: -1, set: true}
+}
+
+// class returns CSS classes of the style
+func (s *style) class() string {
+	if !s.set {
+		return ""
+	}
+
+	var classes []string
+	if s.bold {
+		classes = append(classes, "ansi-bold")
+	}
+	if s.faint {
+		classes = append(classes, "ansi-faint")
+	}
+	if s.italic {
+		classes = append(classes, "ansi-italic")
+	}
+	if s.underline {
+		classes = append(classes, "ansi-underline")
+	}
+	if s.fg >= 0 {
+		classes = append(classes, "ansi-fg-"+strconv.Itoa(s.fg))
+	}
+	if s.bg >= 0 {
+		classes = append(classes, "ansi-bg-"+strconv.Itoa(s.bg))
+	}
+
+	return strings.Join(classes, " ")
+}
@@ -0,0 +1,128 @@
+package buildlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// render returns the spans of the lines as "class:text|class:text" per line
+func render(lines []*Line) []string {
+	var result []string
+	for _, line := range lines {
+		var spans []string
+		for _, span := range line.Spans {
+			spans = append(spans, span.Class+":"+span.Text)
+		}
+		result = append(result, strings.Join(spans, "|"))
+	}
+
+	return result
+}
+
+func TestParseStyles(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		want []string
+	}{
+		{name: "plain", log: "go build\nok", want: []string{":go build", ":ok"}},
+		{name: "empty line", log: "a\n\nb\n", want: []string{":a", "", ":b"}},
+		{name: "CRLF", log: "a\r\nb\r\n", want: []string{":a", ":b"}},
+		{
+			name: "colors",
+			log:  "\x1b[1;31mFAIL\x1b[0m pkg\n\x1b[32mok\x1b[m",
+			want: []string{"ansi-bold ansi-fg-1:FAIL|: pkg", "ansi-fg-2:ok"},
+		},
+		{name: "style is kept between lines", log: "\x1b[33mwarn\nstill\x1b[39m\n", want: []string{"ansi-fg-3:warn", "ansi-fg-3:still"}},
+		{name: "bright colors", log: "\x1b[91;104mx", want: []string{"ansi-fg-9 ansi-bg-12:x"}},
+		{name: "256 colors", log: "\x1b[38;5;13ma\x1b[38;5;200mb\x1b[48;2;1;2;3mc", want: []string{
+			"ansi-fg-13:a|:b|:c",
+		}},
+		{name: "attributes", log: "\x1b[1;3;4mx\x1b[22;23;24my", want: []string{"ansi-bold ansi-italic ansi-underline:x|:y"}},
+		{name: "other sequences are dropped", log: "\x1b[2K\x1b[1Gdone\x1b(B\x07\n", want: []string{":done"}},
+		{name: "character set", log: "\x1b(Bplain\x1b[m", want: []string{":plain"}},
+		{name: "window title", log: "\x1b]0;title\x07a\x1b]8;;http://x\x1b\\b\n", want: []string{":ab"}},
+		{name: "unfinished sequence", log: "a\x1b[1", want: []string{":a"}},
+		{name: "carriage return", log: "10%\r50%\r100%\n", want: []string{":100%"}},
+		{name: "carriage return keeps style", log: "\x1b[32m10%\r100%\r\n", want: []string{"ansi-fg-2:100%"}},
+		{name: "HTML is kept as text", log: "<script>alert(1)</script>", want: []string{":<script>alert(1)</script>"}},
+	}
+
+	for _, test := range tests {
+		if got := render(Parse(test.log)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: Parse(%q) = %q, want %q", test.name, test.log, got, test.want)
+		}
+	}
+}
+
+func TestParseSections(t *testing.T) {
+	log := "setup\n" +
+		"travis_fold:start:install\r\x1b[0Kinstall deps\n" +
+		"fetched\n" +
+		"travis_fold:end:install\r\x1b[0K\n" +
+		"::group::Run tests\n" +
+		strings.Repeat("ok\n", FoldLines+1) +
+		"::endgroup::\n" +
+		"done\n"
+
+	lines := Parse(log)
+	for i, line := range lines {
+		if line.Number != i+1 {
+			t.Fatalf("line %d has number %d, the markers shouldn't be counted", i+1, line.Number)
+		}
+	}
+
+	sections := Sections(lines)
+	var got []string
+	for _, section := range sections {
+		got = append(got, section.Name)
+	}
+	want := []string{"", "install", "Run tests", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sections = %q, want %q", got, want)
+	}
+
+	if text := render(sections[1].Lines); !reflect.DeepEqual(text, []string{":install deps", ":fetched"}) {
+		t.Errorf("install section = %q, the text after the marker should be kept", text)
+	}
+	if sections[1].Folded() {
+		t.Error("short section is folded")
+	}
+	if !sections[2].Folded() {
+		t.Errorf("section of %d lines isn't folded", len(sections[2].Lines))
+	}
+	if sections[0].Folded() || sections[3].Folded() {
+		t.Error("lines out of sections are folded")
+	}
+}
+
+func TestParserWrite(t *testing.T) {
+	log := "\x1b[31mred\nline one\n::group::build\nlong li" + "ne\x1b[0m\nlast"
+
+	// The same lines are returned however the log is split into the parts
+	want := render(Parse(log))
+	for size := 1; size <= len(log); size++ {
+		p := &Parser{}
+		var lines []*Line
+		for i := 0; i < len(log); i += size {
+			end := i + size
+			if end > len(log) {
+				end = len(log)
+			}
+			lines = append(lines, p.Write(log[i:end])...)
+		}
+
+		if partial := p.Partial(); partial == nil || render([]*Line{partial})[0] != ":last" {
+			t.Errorf("part size %d: Partial() = %v, want the last line", size, partial)
+		}
+		lines = append(lines, p.Flush()...)
+
+		if got := render(lines); !reflect.DeepEqual(got, want) {
+			t.Errorf("part size %d: lines = %q, want %q", size, got, want)
+		}
+		if p.Lines() != len(want) {
+			t.Errorf("part size %d: Lines() = %d, want %d", size, p.Lines(), len(want))
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package builds extends github-integration client with the calls the ui needs
+// which are not supported by the client yet
+package builds
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	ghint "github.com/k8s-community/github-integration/client"
+)
+
+const buildResultsURLStr = "/build-results"
+
+// ErrNotFound is returned if there is no such a build
+var ErrNotFound = errors.New("build not found")
+
+// Build is a build of the user's repository kept by github-integration (without the log)
+type Build struct {
+	UUID       string    `json:"uuid"`
+	Username   string    `json:"username"`
+	Repository string    `json:"repository"`
+	CommitHash string    `json:"commitHash"`
+	State      string    `json:"state"` // one of ghint.State* constants, empty for the builds of older versions
+	Passed     bool      `json:"passed"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Status returns the state of the build, it's derived from Passed if github-integration doesn't report it
+func (b *Build) Status() string {
+	if b.State != "" {
+		return b.State
+	}
+
+	if b.Passed {
+		return ghint.StateSuccess
+	}
+
+	return ghint.StateFailure
+}
+
+// Final tells if the build is finished, the builds of older versions of github-integration
+// without the state are always finished
+func (b *Build) Final() bool {
+	return b.State != ghint.StatePending
+}
+
+// ShortHash returns the abbreviated commit hash
+func (b *Build) ShortHash() string {
+	if len(b.CommitHash) > 7 {
+		return b.CommitHash[:7]
+	}
+
+	return b.CommitHash
+}
+
+// Results is the build with its log
+type Results struct {
+	Build
+	Log string `json:"log"`
+
+	// Offset is the length of the log in bytes, the next part of the log starts from it
+	Offset int `json:"offset"`
+}
+
+// ListOptions defines the builds to list
+type ListOptions struct {
+	Username   string
+	Repository string // all the repositories if it's empty
+
+	// Page starts from 1
+	Page    int
+	PerPage int
+}
+
+// List is a page of the builds, the newest first
+type List struct {
+	Builds []*Build `json:"builds"`
+	Total  int      `json:"total"` // number of the builds matching the options
+}
+
+// Client is github-integration client with the additional calls
+type Client struct {
+	*ghint.Client
+}
+
+// NewClient wraps github-integration client
+func NewClient(client *ghint.Client) *Client {
+	return &Client{Client: client}
+}
+
+// List returns the builds of the user:
+// GET /api/v1/build-results?username=<username>&repository=<repository>&page=<page>&perPage=<perPage>
+func (c *Client) List(options ListOptions) (*List, error) {
+	query := url.Values{}
+	query.Set("username", options.Username)
+	if options.Repository != "" {
+		query.Set("repository", options.Repository)
+	}
+	query.Set("page", strconv.Itoa(options.Page))
+	query.Set("perPage", strconv.Itoa(options.PerPage))
+
+	req, err := c.NewRequest(http.MethodGet, buildResultsURLStr+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &List{}
+	resp, err := c.Do(req, list)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list builds of user %s: %v", options.Username, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("couldn't list builds of user %s: %s", options.Username, resp.Status)
+	}
+
+	return list, nil
+}
+
+// Results returns the build and its log starting from the offset (in bytes):
+// GET /api/v1/build-results/<uuid>?offset=<offset>
+// The versions of github-integration which don't support the offset return the whole log, it's cut here then.
+func (c *Client) Results(uuid string, offset int) (*Results, error) {
+	urlStr := buildResultsURLStr + "/" + url.PathEscape(uuid)
+	if offset > 0 {
+		urlStr += "?offset=" + strconv.Itoa(offset)
+	}
+
+	req, err := c.NewRequest(http.MethodGet, urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &Results{}
+	resp, err := c.Do(req, results)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get results for uuid %s: %v", uuid, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("couldn't get results for uuid %s: %s", uuid, resp.Status)
+	}
+	if results.UUID == "" {
+		return nil, ErrNotFound
+	}
+
+	if results.Offset == 0 {
+		// The offset isn't supported, the whole log is returned
+		if offset > len(results.Log) {
+			offset = len(results.Log)
+		}
+		results.Log = results.Log[offset:]
+		results.Offset = offset + len(results.Log)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,218 @@
+package builds
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/k8s-community/ui/buildlog"
+)
+
+// updatesBuffer is the number of the updates kept for the viewer which is slow to receive them,
+// the viewer is dropped when the buffer is full (it reconnects from the last line it has)
+const updatesBuffer = 16
+
+// Update is the change of the build sent to the viewers
+type Update struct {
+	Lines []*buildlog.Line // new lines, the last line of the previous update is sent again if it was unfinished
+	Build Build
+}
+
+// Tailer polls github-integration for the logs of the running builds and fans the new lines out
+// to the viewers. A build is polled once however many viewers watch it.
+type Tailer struct {
+	client   *Client
+	logger   logrus.FieldLogger
+	interval time.Duration
+
+	mux   sync.Mutex
+	tails map[string]*tail
+
+	done  chan struct{}
+	close sync.Once
+}
+
+// tail is the state of the watched build
+type tail struct {
+	uuid    string
+	parser  buildlog.Parser
+	offset  int
+	lines   []*buildlog.Line
+	partial *buildlog.Line      // the unfinished last line, it's shown while the build is running
+	build   *Build              // nil until the first poll
+	viewers map[chan Update]int // the number of the next line the viewer needs
+}
+
+// NewTailer creates Tailer which polls the builds with the interval
+func NewTailer(client *Client, logger logrus.FieldLogger, interval time.Duration) *Tailer {
+	return &Tailer{
+		client:   client,
+		logger:   logger.WithField("component", "build-tail"),
+		interval: interval,
+		tails:    make(map[string]*tail),
+		done:     make(chan struct{}),
+	}
+}
+
+// Subscribe starts watching the build from the line number (the lines before it are known to the viewer).
+// The channel is closed when the build is finished, the viewer is too slow or the tailer is closed.
+// The returned function should be called when the viewer leaves.
+func (t *Tailer) Subscribe(uuid string, from int) (<-chan Update, func()) {
+	updates := make(chan Update, updatesBuffer)
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	select {
+	case <-t.done:
+		close(updates)
+		return updates, func() {}
+	default:
+	}
+
+	tl, ok := t.tails[uuid]
+	if !ok {
+		tl = &tail{uuid: uuid, viewers: make(map[chan Update]int)}
+		t.tails[uuid] = tl
+		go t.poll(tl)
+	}
+	tl.viewers[updates] = from
+
+	// Catch up with the lines parsed already
+	if tl.build != nil {
+		updates <- Update{Lines: tl.since(from), Build: *tl.build}
+		tl.viewers[updates] = len(tl.lines) + 1
+	}
+
+	unsubscribe := func() {
+		t.mux.Lock()
+		defer t.mux.Unlock()
+
+		if _, ok := tl.viewers[updates]; ok {
+			delete(tl.viewers, updates)
+			close(updates)
+		}
+	}
+
+	return updates, unsubscribe
+}
+
+// Close finishes all the subscriptions, it should be called on shutdown
+func (t *Tailer) Close() {
+	t.close.Do(func() {
+		close(t.done)
+	})
+}
+
+// poll fetches the log of the build until it's finished or nobody watches it
+func (t *Tailer) poll(tl *tail) {
+	logger := t.logger.WithField("build", tl.uuid)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		results, err := t.client.Results(tl.uuid, tl.offset)
+		if err == ErrNotFound {
+			logger.Infof("Build isn't found, stop tailing")
+			t.finish(tl)
+			return
+		}
+		if err != nil {
+			logger.Errorf("Couldn't get build log: %+v", err)
+		} else if t.publish(tl, results) {
+			return
+		}
+
+		select {
+		case <-t.done:
+			t.finish(tl)
+			return
+		case <-ticker.C:
+		}
+
+		t.mux.Lock()
+		idle := len(tl.viewers) == 0
+		if idle {
+			delete(t.tails, tl.uuid)
+		}
+		t.mux.Unlock()
+		if idle {
+			return
+		}
+	}
+}
+
+// publish parses the new part of the log and sends it to the viewers,
+// true is returned if the build is finished and the viewers are gone
+func (t *Tailer) publish(tl *tail, results *Results) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	tl.offset = results.Offset
+	tl.lines = append(tl.lines, tl.parser.Write(results.Log)...)
+
+	final := results.Build.Final()
+	if final {
+		tl.lines = append(tl.lines, tl.parser.Flush()...)
+		tl.partial = nil
+	} else {
+		tl.partial = tl.parser.Partial()
+	}
+
+	changed := tl.build == nil || tl.build.State != results.State || tl.build.Passed != results.Passed ||
+		len(results.Log) > 0
+	tl.build = &results.Build
+
+	if changed {
+		for updates, from := range tl.viewers {
+			select {
+			case updates <- Update{Lines: tl.since(from), Build: results.Build}:
+				tl.viewers[updates] = len(tl.lines) + 1
+			default:
+				// The viewer doesn't keep up, it will reconnect
+				delete(tl.viewers, updates)
+				close(updates)
+			}
+		}
+	}
+
+	if final {
+		for updates := range tl.viewers {
+			delete(tl.viewers, updates)
+			close(updates)
+		}
+		delete(t.tails, tl.uuid)
+	}
+
+	return final
+}
+
+// finish closes the subscriptions of the build
+func (t *Tailer) finish(tl *tail) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	for updates := range tl.viewers {
+		delete(tl.viewers, updates)
+		close(updates)
+	}
+	delete(t.tails, tl.uuid)
+}
+
+// since returns the lines starting from the number including the unfinished one
+func (tl *tail) since(from int) []*buildlog.Line {
+	if from < 1 {
+		from = 1
+	}
+
+	var lines []*buildlog.Line
+	if from <= len(tl.lines) {
+		lines = append(lines, tl.lines[from-1:]...)
+	}
+	if tl.partial != nil && tl.partial.Number >= from {
+		lines = append(lines, tl.partial)
+	}
+
+	return lines
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/k8s-community/ui/config"
+	"github.com/k8s-community/ui/invitations"
+)
+
+const invitationsUsage = `Usage: %s invitations [flags] create [-uses N] [-ttl duration] [-workshop name] [-code code] | list
+
+  create  create a new invitation code
+  list    show all invitation codes
+`
+
+// manageInvitations runs "invitations" subcommand
+func manageInvitations(name string, args []string) {
+	cfg, rest, err := config.LoadDB(name+" invitations", args)
+	if err != nil {
+		logrus.Fatalf("Couldn't manage invitations: %+v", err)
+	}
+
+	if len(rest) < 1 {
+		fmt.Fprintf(os.Stderr, invitationsUsage, name)
+		os.Exit(2)
+	}
+
+	logger := newLogger(cfg).WithFields(logrus.Fields{"service": "ui"})
+
+	db, err := startupDB(cfg.DataSource(), logger)
+	if err != nil {
+		logger.Fatalf("Couldn't start up DB: %+v", err)
+	}
+
+	switch rest[0] {
+	case "create":
+		var options invitations.Options
+
+		flags := flag.NewFlagSet(name+" invitations create", flag.ExitOnError)
+		flags.IntVar(&options.MaxUses, "uses", 1, "number of users who can enroll with the code")
+		flags.DurationVar(&options.TTL, "ttl", 0, "time the code is valid, the code doesn't expire by default")
+		flags.StringVar(&options.Workshop, "workshop", "", "workshop the code is issued for")
+		flags.StringVar(&options.Code, "code", "", "the code, it's generated by default")
+		flags.Parse(rest[1:])
+
+		if options.MaxUses <= 0 {
+			logger.Fatalf("Number of uses must be positive")
+		}
+
+		invitation, err := invitations.Create(db, options)
+		if err != nil {
+			logger.Fatalf("Couldn't create invitation code: %+v", err)
+		}
+
+		fmt.Println(invitation.Code)
+	case "list":
+		list, err := invitations.List(db)
+		if err != nil {
+			logger.Fatalf("Couldn't list invitation codes: %+v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tWORKSHOP\tUSES\tEXPIRES AT")
+		for _, invitation := range list {
+			workshop, expiresAt := "-", "never"
+			if invitation.Workshop != nil {
+				workshop = *invitation.Workshop
+			}
+			if invitation.ExpiresAt != nil {
+				expiresAt = invitation.ExpiresAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\n", invitation.Code, workshop, invitation.Uses, invitation.MaxUses, expiresAt)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintf(os.Stderr, invitationsUsage, name)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/k8s-community/ui/kubeconfig"
+	"github.com/k8s-community/ui/version"
+)
+
+// deviceGrantType is the grant type of the device authorization flow (RFC 8628)
+const deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// slowDownStep is added to the polling interval when the site asks to slow down
+const slowDownStep = 5 * time.Second
+
+// client calls the workshop site
+type client struct {
+	url  string
+	http *http.Client
+}
+
+// deviceCode is the response to the device authorization request
+type deviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// token is the response to the token request, Error is set if the token isn't issued
+type token struct {
+	AccessToken string `json:"access_token"`
+	Error       string `json:"error"`
+}
+
+// credentials are the user's Kubernetes credentials
+type credentials struct {
+	Kubeconfig string `json:"kubeconfig"`
+}
+
+// apiError is the error returned by the API of the site
+type apiError struct {
+	Error     string `json:"error"`
+	RequestID string `json:"requestId"`
+}
+
+func newClient(server string) *client {
+	return &client{
+		url:  strings.TrimSuffix(server, "/"),
+		http: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// login signs in with the device authorization flow and merges the kubeconfig into the file
+func login(c *client, path string) error {
+	code, err := c.deviceCode()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("To sign in, open %s and enter the code %s\n", code.VerificationURI, code.UserCode)
+	fmt.Printf("or just open %s\n\n", code.VerificationURIComplete)
+	fmt.Println("Waiting for the approval...")
+
+	accessToken, err := c.waitToken(code)
+	if err != nil {
+		return err
+	}
+
+	creds, err := c.credentials(accessToken)
+	if err != nil {
+		return err
+	}
+
+	config := &kubeconfig.Config{}
+	if err = yaml.Unmarshal([]byte(creds.Kubeconfig), config); err != nil {
+		return fmt.Errorf("couldn't parse kubeconfig: %v", err)
+	}
+
+	existing, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	merged, err := kubeconfig.Merge(existing, config)
+	if err != nil {
+		return fmt.Errorf("couldn't merge kubeconfig %s: %v", path, err)
+	}
+
+	if err = writeFile(path, merged); err != nil {
+		return err
+	}
+
+	fmt.Printf("Context %s was added to %s and made current\n", config.CurrentContext, path)
+	return nil
+}
+
+// deviceCode starts the device authorization flow
+func (c *client) deviceCode() (*deviceCode, error) {
+	code := &deviceCode{}
+	status, err := c.post("/oauth/device/code", url.Values{}, code)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusTooManyRequests {
+		return nil, errors.New("too many sign ins have been started from this address, please try again later")
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("couldn't start sign in: %d %s", status, http.StatusText(status))
+	}
+
+	return code, nil
+}
+
+// waitToken polls the site until the user approves the sign in
+func (c *client) waitToken(code *deviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		result := &token{}
+		status, err := c.post("/oauth/device/token", url.Values{
+			"grant_type":  {deviceGrantType},
+			"device_code": {code.DeviceCode},
+		}, result)
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case status == http.StatusOK && result.AccessToken != "":
+			return result.AccessToken, nil
+		case result.Error == "authorization_pending":
+		case result.Error == "slow_down":
+			interval += slowDownStep
+		case result.Error == "access_denied":
+			return "", errors.New("the sign in was denied")
+		case result.Error == "expired_token":
+			return "", errors.New("the code has expired, please run login again")
+		case result.Error != "":
+			return "", fmt.Errorf("the sign in failed: %s", result.Error)
+		default:
+			return "", fmt.Errorf("the sign in failed: %d %s", status, http.StatusText(status))
+		}
+	}
+
+	return "", errors.New("the code has expired, please run login again")
+}
+
+// credentials returns the user's Kubernetes credentials
+func (c *client) credentials(accessToken string) (*credentials, error) {
+	req, err := http.NewRequest(http.MethodGet, c.url+"/api/v1/me/credentials", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	creds := &credentials{}
+	status, err := c.do(req, creds)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("couldn't get credentials: %d %s", status, http.StatusText(status))
+	}
+
+	return creds, nil
+}
+
+// post sends the form and decodes the JSON response into the result
+func (c *client) post(path string, form url.Values, result interface{}) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, c.url+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.do(req, result)
+}
+
+// do sends the request and decodes the JSON response into the result.
+// The errors of the API are returned as errors, the status is returned for the other responses.
+func (c *client) do(req *http.Request, result interface{}) (int, error) {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "k8s-community/"+version.RELEASE)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return resp.StatusCode, nil
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusNotFound {
+		// The message for the user is returned by the site
+		e := apiError{}
+		if json.Unmarshal(body, &e) == nil && e.RequestID != "" {
+			return resp.StatusCode, fmt.Errorf("%s (request ID %s)", e.Error, e.RequestID)
+		}
+	}
+
+	return resp.StatusCode, json.Unmarshal(body, result)
+}
+
+// writeFile replaces the file, it's readable only by the user since it contains the credentials
+func writeFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,75 @@
+// Command k8s-community is the CLI of the workshop participants.
+// "login" signs in with the device authorization flow, so it works on the machines without a browser,
+// and merges the kubeconfig with the user's Kubernetes credentials into ~/.kube/config.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/k8s-community/ui/version"
+)
+
+const usage = `Usage: %s login [flags]
+
+  login    sign in and add your Kubernetes credentials to kubeconfig
+  version  show the version
+
+Flags of login:
+`
+
+func main() {
+	name := filepath.Base(os.Args[0])
+	flags := flag.NewFlagSet(name+" login", flag.ExitOnError)
+	server := flags.String("url", os.Getenv("K8S_COMMUNITY_URL"), "URL of the workshop site (K8S_COMMUNITY_URL)")
+	path := flags.String("kubeconfig", "", "kubeconfig file to update, the first file of KUBECONFIG or ~/.kube/config by default")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, usage, name)
+		flags.PrintDefaults()
+	}
+
+	if len(os.Args) < 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "login":
+		flags.Parse(os.Args[2:])
+
+		if *server == "" {
+			fmt.Fprintln(os.Stderr, "URL of the workshop site is required (-url or K8S_COMMUNITY_URL)")
+			os.Exit(2)
+		}
+
+		if err := login(newClient(*server), kubeconfigPath(*path)); err != nil {
+			fmt.Fprintf(os.Stderr, "Couldn't sign in: %v\n", err)
+			os.Exit(1)
+		}
+	case "version":
+		fmt.Printf("%s %s (%s)\n", name, version.RELEASE, version.COMMIT)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+}
+
+// kubeconfigPath returns the kubeconfig file to update as kubectl finds it
+func kubeconfigPath(path string) string {
+	if path != "" {
+		return path
+	}
+
+	if paths := filepath.SplitList(os.Getenv("KUBECONFIG")); len(paths) > 0 && paths[0] != "" {
+		return paths[0]
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	return filepath.Join(home, ".kube", "config")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Sirupsen/logrus"
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/config"
+	"github.com/k8s-community/ui/db/migrations"
+)
+
+const migrateUsage = `Usage: %s migrate [flags] up|down|status|redo
+
+  up      apply all pending migrations
+  down    roll back the last applied migration
+  status  show applied and pending migrations
+  redo    roll back and apply again the last applied migration
+`
+
+// migrate runs "migrate" subcommand
+func migrate(name string, args []string) {
+	cfg, rest, err := config.LoadDB(name+" migrate", args)
+	if err != nil {
+		logrus.Fatalf("Couldn't run migrations: %+v", err)
+	}
+
+	if len(rest) != 1 {
+		fmt.Fprintf(os.Stderr, migrateUsage, name)
+		os.Exit(2)
+	}
+
+	logger := newLogger(cfg).WithFields(logrus.Fields{"service": "ui"})
+
+	db, err := startupDB(cfg.DataSource(), logger)
+	if err != nil {
+		logger.Fatalf("Couldn't start up DB: %+v", err)
+	}
+
+	migrator, err := migrations.New(db, logger)
+	if err != nil {
+		logger.Fatalf("Couldn't load migrations: %+v", err)
+	}
+
+	switch rest[0] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down()
+	case "redo":
+		err = migrator.Redo()
+	case "status":
+		err = printStatus(migrator)
+	default:
+		fmt.Fprintf(os.Stderr, migrateUsage, name)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		logger.Fatalf("Couldn't run migrations: %+v", err)
+	}
+}
+
+func printStatus(migrator *migrations.Migrator) error {
+	states, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, state := range states {
+		appliedAt := "pending"
+		if state.AppliedAt != nil {
+			appliedAt = state.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", state.Version, state.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
+
+// checkSchema refuses to start with the schema behind the binary unless auto migration is enabled
+func checkSchema(db *reform.DB, logger logrus.FieldLogger, autoMigrate bool) error {
+	migrator, err := migrations.New(db, logger)
+	if err != nil {
+		return err
+	}
+
+	if autoMigrate {
+		return migrator.Up()
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf(
+			"database schema is behind: %d migration(s) pending, run 'migrate up' or enable auto migration", len(pending),
+		)
+	}
+
+	return nil
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"flag"
-	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -18,204 +20,261 @@ import (
 	"gopkg.in/reform.v1"
 	"gopkg.in/reform.v1/dialects/postgresql"
 
+	"github.com/k8s-community/ui/access"
+	"github.com/k8s-community/ui/builds"
+	"github.com/k8s-community/ui/config"
 	"github.com/k8s-community/ui/handlers"
+	"github.com/k8s-community/ui/providers"
+	"github.com/k8s-community/ui/provisioning"
 	"github.com/k8s-community/ui/session/storage"
 	"github.com/k8s-community/ui/version"
+	"github.com/k8s-community/ui/views"
 )
 
-var log logrus.Logger
-
 func main() {
-
-	// --use-service-discovery true|false
-
-	var serviceDiscovery = flag.Bool("sd", true, "service discovery")
-	flag.Parse()
-
-	log := logrus.New()
-	log.Formatter = new(logrus.TextFormatter)
-	log.Level = logrus.DebugLevel
-	logger := log.WithFields(logrus.Fields{"service": "ui"})
-
-	var namespace string
-	if *serviceDiscovery {
-		var err error
-		namespace, err = getFromEnv("NAMESPACE")
-		if err != nil {
-			logger.Fatalf("Namespace is not set: %+v", err)
-		}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[0], os.Args[2:])
+		return
 	}
 
-	var errors []error
-
-	dbUser, err := getFromEnv("UIDB_USER")
-	if err != nil {
-		errors = append(errors, err)
+	if len(os.Args) > 1 && os.Args[1] == "invitations" {
+		manageInvitations(os.Args[0], os.Args[2:])
+		return
 	}
 
-	dbPass, err := getFromEnv("UIDB_PASSWORD")
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
 	if err != nil {
-		errors = append(errors, err)
+		logrus.Fatalf("Couldn't start service: %+v", err)
 	}
 
-	dbName, err := getFromEnv("UIDB_NAME")
-	if err != nil {
-		errors = append(errors, err)
-	}
+	logger := newLogger(cfg).WithFields(logrus.Fields{"service": "ui"})
 
-	if len(errors) > 0 {
-		logger.Fatalf("Couldn't start service because required DB parameters are not set: %+v", errors)
-	}
-
-	var dbHost, dbPort string
-
-	if len(namespace) == 0 {
-		var err error
-		dbHost, err = getFromEnv("DB_HOST")
-
-		if err != nil {
-			logger.Fatalf("Couldn't start service %+v", err)
-		}
-
-		dbPort, err = getFromEnv("DB_PORT")
-
-		if err != nil {
-			logger.Fatalf("Couldn't start service %+v", err)
-		}
-
-	} else {
-		dbHost = fmt.Sprintf("%s.%s", "uidb", namespace)
-		dbPort = "5432"
+	db, err := startupDB(cfg.DataSource(), logger)
+	if err != nil {
+		logger.Fatalf("Couldn't start up DB: %+v", err)
 	}
 
-	db, err := startupDB(dbHost, dbPort, dbUser, dbPass, dbName)
-	if err != nil {
-		log.Fatalf("Couldn't start up DB for %v:%v: %+v", dbHost, dbPort, err)
+	if err := checkSchema(db, logger, cfg.DB.AutoMigrate); err != nil {
+		logger.Fatalf("Couldn't start service: %+v", err)
 	}
 
-	// Session manager settings: temporary solution
 	session.Global.Close()
 	cookieMngrOptions := &session.CookieMngrOptions{
-		SessIDCookieName: "k8s-community-session-id",
-		AllowHTTP:        true,
-		CookieMaxAge:     48 * time.Hour,
+		SessIDCookieName: cfg.Cookie.Name,
+		AllowHTTP:        cfg.Cookie.AllowHTTP,
+		CookieMaxAge:     cfg.Cookie.MaxAge,
+		CookiePath:       cfg.Cookie.Path,
 	}
-	sessionStorage := storage.NewDB(db, logger)
+	sessionStorage := storage.NewDB(db, logger, storage.Options{
+		IdleTimeout:   cfg.Session.IdleTimeout,
+		MaxAge:        cfg.Session.MaxAge,
+		SweepInterval: cfg.Session.SweepInterval,
+	})
 	session.Global = session.NewCookieManagerOptions(sessionStorage, cookieMngrOptions)
 
-	serviceHost, err := getFromEnv("SERVICE_HOST")
-	if err != nil {
-		errors = append(errors, err)
-	}
-
-	servicePort, err := getFromEnv("SERVICE_PORT")
-	if err != nil {
-		errors = append(errors, err)
-	}
-
-	usermanBaseURL := fmt.Sprintf("http://user-manager.%s:80", namespace)
-	ghintBaseURL := fmt.Sprintf("http://github-integration.%s:80", namespace)
-
-	githubClientID, err := getFromEnv("GITHUB_CLIENT_ID")
+	// Init user-manager client to be able to create user in Kubernetes
+	// The timeout is less than the lease of provisioning jobs, so a hung request doesn't make the job run twice
+	usermanClient, err := umClient.NewClient(&http.Client{Timeout: time.Minute}, cfg.Clients.UserManagerURL)
 	if err != nil {
-		errors = append(errors, err)
+		logger.Fatalf("Couldn't get an instance of user-manager's service client: %+v", err)
 	}
 
-	githubClientSecret, err := getFromEnv("GITHUB_CLIENT_SECRET")
+	// Init github-integration client to get info about the builds
+	ghintClient, err := ghint.NewClient(nil, cfg.Clients.GitHubIntegrationURL)
 	if err != nil {
-		errors = append(errors, err)
+		logger.Fatalf("Couldn't get an instance of github-integration's service client: %+v", err)
 	}
+	buildsClient := builds.NewClient(ghintClient)
+	buildTailer := builds.NewTailer(buildsClient, logger, cfg.Builds.TailInterval)
+
+	provisioningQueue := provisioning.New(db, logger, usermanClient, provisioning.Options{
+		Workers:      cfg.Provisioning.Workers,
+		PollInterval: cfg.Provisioning.PollInterval,
+		MaxAttempts:  cfg.Provisioning.MaxAttempts,
+		MinBackoff:   cfg.Provisioning.MinBackoff,
+		MaxBackoff:   cfg.Provisioning.MaxBackoff,
+	})
 
-	k8sGuestToken, err := getFromEnv("K8S_GUEST_TOKEN")
-	if err != nil {
-		errors = append(errors, err)
+	// OAuth state is random for each login attempt and is bound to the browser with a signed cookie
+	oauthStates := handlers.NewOAuthState(cfg.GitHub.OAuthState, cfg.OAuth.StateTTL, !cfg.Cookie.AllowHTTP)
+	var signInProviders []providers.Provider
+	if cfg.GitHub.ClientID != "" {
+		github, err := providers.NewGitHub(providers.GitHubOptions{
+			ClientID:     cfg.GitHub.ClientID,
+			ClientSecret: cfg.GitHub.ClientSecret,
+			PKCE:         cfg.GitHub.PKCE,
+			AuthURL:      cfg.GitHub.AuthURL,
+			TokenURL:     cfg.GitHub.TokenURL,
+			APIURL:       cfg.GitHub.APIURL,
+			Groups:       len(cfg.Access.Groups) > 0,
+		})
+		if err != nil {
+			logger.Fatalf("Couldn't create GitHub provider: %+v", err)
+		}
+		signInProviders = append(signInProviders, github)
 	}
-
-	// oauthState is a token to protect the user from CSRF attacks
-	oauthState, err := getFromEnv("GITHUB_OAUTH_STATE")
-	if err != nil {
-		errors = append(errors, err)
+	if cfg.GitLab.ClientID != "" {
+		gitlab, err := providers.NewGitLab(
+			cfg.GitLab.URL, cfg.GitLab.ClientID, cfg.GitLab.ClientSecret, cfg.GitLab.RedirectURL, cfg.GitLab.PKCE,
+		)
+		if err != nil {
+			logger.Fatalf("Couldn't create GitLab provider: %+v", err)
+		}
+		signInProviders = append(signInProviders, gitlab)
 	}
-
-	if len(errors) > 0 {
-		logger.Fatalf("Couldn't start service because required parameters are not set: %+v", errors)
+	if cfg.OIDC.Issuer != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		oidc, err := providers.NewOIDC(ctx, providers.OIDCOptions{
+			Title:        cfg.OIDC.Title,
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+			Claim:        cfg.OIDC.Claim,
+			PKCE:         cfg.OIDC.PKCE,
+			HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+		})
+		cancel()
+		if err != nil {
+			logger.Fatalf("Couldn't create OpenID Connect provider: %+v", err)
+		}
+		signInProviders = append(signInProviders, oidc)
 	}
 
-	// Init user-manager client to be able to create user in Kubernetes
-	usermanClient, err := umClient.NewClient(nil, usermanBaseURL)
+	accessRules := access.Rules{Allow: cfg.Access.Allow, Block: cfg.Access.Block, Groups: cfg.Access.Groups}
+	roles := access.Roles{Admins: cfg.Access.Admins, Instructors: cfg.Access.Instructors}
+	buildAccess := access.Builds{Roles: roles, Public: cfg.Builds.Public}
+	// Fail fast if any template is broken
+	pages, err := views.New(cfg.Templates.Dir, logger)
 	if err != nil {
-		logger.Fatalf("Couldn't get an instance of user-manager's service client: %+v", err)
+		logger.Fatalf("Couldn't parse templates: %+v", err)
 	}
-
-	// Init github-integration client to get info about the builds
-	ghintClient, err := ghint.NewClient(nil, ghintBaseURL)
-	if err != nil {
-		logger.Fatalf("Couldn't get an instance of github-integration's service client: %+v", err)
+	if cfg.Templates.Watch {
+		pages.Watch(time.Second)
+		defer pages.Close()
 	}
+	errorPages := handlers.NewErrorPages(logger, pages)
 
-	githubHandler := handlers.NewGitHubOAuth(logger, usermanClient, oauthState, githubClientID, githubClientSecret)
-
-	// TODO: add graceful shutdown
+	oauthHandler := handlers.NewOAuth(
+		logger, pages, errorPages, db, provisioningQueue, oauthStates, accessRules, cfg.Invitations.Required, signInProviders...,
+	)
+	health := &handlers.Health{}
+	events := handlers.NewEvents(db, logger, errorPages, cfg.Provisioning.StatusInterval)
+	cluster := handlers.Cluster{Name: cfg.Kubernetes.ClusterName, APIServer: cfg.Kubernetes.APIServer}
+	api := handlers.NewAPI(db, logger, errorPages, buildsClient, buildAccess, cluster)
+	accessTokens := handlers.NewTokens(db, logger, pages, errorPages)
+	device := handlers.NewDevice(
+		db, logger, pages, errorPages, oauthHandler.SignInLinks(), cfg.Service.PublicURL, cfg.OAuth.DeviceCodeTTL, cfg.OAuth.DevicePollInterval,
+	)
+	admin := handlers.NewAdmin(
+		db, logger, pages, errorPages, roles, provisioningQueue, buildsClient, cfg.Builds.History,
+	)
 
 	r := router.New()
 	r.Handler("GET", "/static/*", http.StripPrefix("/static", http.FileServer(http.Dir("./static"))))
-	r.GET("/", handlers.Home(db, logger, k8sGuestToken))
-	r.GET("/oauth/github", githubHandler.Login)
-	r.GET("/oauth/github-cb", githubHandler.Callback)
+	home := handlers.Home(
+		db, logger, pages, errorPages,
+		cfg.Kubernetes.GuestToken, oauthHandler.SignInLinks(), cfg.Invitations.Required, cfg.Builds.History, roles,
+	)
+	r.GET("/", home)
+	r.GET("/join", home)
+	r.GET("/join/:code", home)
+	r.GET("/oauth/:provider", oauthHandler.Handle)
+	r.POST("/oauth/device/code", device.Code)
+	r.POST("/oauth/device/token", device.Token)
+	r.GET("/device", device.Page)
+	r.POST("/device", device.Decide)
 	r.GET("/signout", handlers.Signout())
-	r.GET("/builds/:uuid", handlers.BuildHistory(ghintClient, "en"))
-
-	r.GET("/builds/:id", func(c *router.Control) {
-		c.Code(http.StatusOK).Body(http.StatusText(http.StatusOK))
-	})
+	r.GET("/events", events.Stream)
+	r.GET("/events/status", events.Status)
+	r.GET("/kubeconfig", handlers.Kubeconfig(db, logger, errorPages, cluster))
+	if cfg.Builds.History {
+		r.GET("/builds", handlers.BuildList(buildsClient, logger, pages))
+	}
+	r.GET("/builds/:uuid", handlers.BuildHistory(buildsClient, buildAccess, cfg.Builds.History, logger, pages, errorPages))
+	r.GET("/builds/:uuid/log", handlers.BuildLogStream(buildsClient, buildTailer, buildAccess, logger, pages, errorPages))
+	r.GET("/settings/tokens", accessTokens.Page)
+	r.POST("/settings/tokens", accessTokens.Create)
+	r.POST("/settings/tokens/:id/revoke", accessTokens.Revoke)
+	r.GET("/admin", admin.Page)
+	r.POST("/admin/users/:id", admin.Act)
+
+	r.GET("/api/v1/me", api.Me)
+	r.GET("/api/v1/me/credentials", api.Credentials)
+	if cfg.Builds.History {
+		r.GET("/api/v1/builds", api.Builds)
+	}
+	r.GET("/api/v1/builds/:uuid", api.Build)
+	r.GET("/api/v1/openapi.yaml", handlers.APIDescription("./static/api/openapi.yaml"))
 
 	r.GET("/info", info.Handler(version.RELEASE, version.REPO, version.COMMIT))
-	r.GET("/healthz", func(c *router.Control) {
-		c.Code(http.StatusOK).Body(http.StatusText(http.StatusOK))
-	})
+	r.GET("/healthz", health.Healthz)
+
+	r.NotFound = errorPages.NotFound
+	r.CustomHandler = errorPages.Recover
+	r.PanicHandler = errorPages.Panic
+
+	hostPort := cfg.HostPort()
+	server := &http.Server{Addr: hostPort, Handler: handlers.RequestID(r)}
+	// The event streams are never idle, so they have to be finished explicitly
+	server.RegisterOnShutdown(events.Close)
+	server.RegisterOnShutdown(buildTailer.Close)
+
+	go func() {
+		logger.Infof("Ready to listen %s\nRoutes: %+v", hostPort, r.Routes())
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatalf("Couldn't listen %s: %+v", hostPort, err)
+		}
+	}()
 
-	r.NotFound = handlers.NotFound(logger)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	logger.Infof("Got signal %v, shutting down...", <-signals)
 
-	hostPort := fmt.Sprintf("%s:%s", serviceHost, servicePort)
-	logger.Infof("Ready to listen %s\nRoutes: %+v", hostPort, r.Routes())
-	r.Listen(hostPort)
-}
+	// Keep serving until the readiness probe sees the failing /healthz and the pod is removed from the endpoints
+	health.Shutdown()
+	time.Sleep(cfg.Service.ShutdownDrain)
 
-func getFromEnv(name string) (string, error) {
-	value := os.Getenv(name)
-	if len(value) == 0 {
-		return "", fmt.Errorf("Environement variable %s must be set", name)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Service.ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting connections and wait for in-flight requests
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Errorf("Couldn't wait for in-flight requests: %+v", err)
 	}
 
-	return value, nil
-}
+	// Wait for running provisioning jobs, the unfinished ones are run again after restart
+	if err := provisioningQueue.Close(ctx); err != nil {
+		logger.Errorf("Couldn't wait for running provisioning jobs: %+v", err)
+	}
 
-// startupDB makes connection with DB, initializes reform DB level.
-func startupDB(host, port, user, password, name string) (*reform.DB, error) {
-	dataSource := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable", user, password, host, port, name,
-	)
+	session.Global.Close()
 
-	conn, err := sql.Open("postgres", dataSource)
-	if err != nil {
-		return nil, err
+	if conn, ok := db.DBInterface().(io.Closer); ok {
+		if err := conn.Close(); err != nil {
+			logger.Errorf("Couldn't close DB connection: %+v", err)
+		}
 	}
 
-	if err = conn.Ping(); err != nil {
-		return nil, err
-	}
+	logger.Info("Service was stopped")
+}
 
-	db := reform.NewDB(conn, postgresql.Dialect, reform.NewPrintfLogger(log.Printf))
-	if err != nil {
-		return nil, err
+// newLogger creates logger with the configured level and format
+func newLogger(cfg *config.Config) *logrus.Logger {
+	log := logrus.New()
+	log.Level, _ = logrus.ParseLevel(cfg.Log.Level)
+	if cfg.Log.Format == "json" {
+		log.Formatter = new(logrus.JSONFormatter)
+	} else {
+		log.Formatter = new(logrus.TextFormatter)
 	}
 
-	return db, nil
+	return log
 }
 
-func startupDBWithConnectionString(connectionString string) (*reform.DB, error) {
-	dataSource := fmt.Sprintf(connectionString)
-
+// startupDB makes connection with DB, initializes reform DB level.
+func startupDB(dataSource string, logger logrus.FieldLogger) (*reform.DB, error) {
 	conn, err := sql.Open("postgres", dataSource)
 	if err != nil {
 		return nil, err
@@ -225,10 +284,5 @@ func startupDBWithConnectionString(connectionString string) (*reform.DB, error)
 		return nil, err
 	}
 
-	db := reform.NewDB(conn, postgresql.Dialect, reform.NewPrintfLogger(log.Printf))
-	if err != nil {
-		return nil, err
-	}
-
-	return db, nil
+	return reform.NewDB(conn, postgresql.Dialect, reform.NewPrintfLogger(logger.Debugf)), nil
 }
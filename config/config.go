@@ -0,0 +1,518 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/k8s-community/ui/access"
+)
+
+// Config contains all settings of the service.
+// Each value can be defined in a config file (YAML), an environment variable or a flag,
+// the latter has the highest priority.
+type Config struct {
+	// ServiceDiscovery defines if the addresses of the DB and the other services
+	// should be discovered using Kubernetes namespace
+	ServiceDiscovery bool   `yaml:"serviceDiscovery" env:"SERVICE_DISCOVERY" flag:"sd" usage:"service discovery"`
+	Namespace        string `yaml:"namespace" env:"NAMESPACE" flag:"namespace" usage:"Kubernetes namespace of the service"`
+
+	Service      Service      `yaml:"service"`
+	Templates    Templates    `yaml:"templates"`
+	Log          Log          `yaml:"log"`
+	DB           DB           `yaml:"db"`
+	Cookie       Cookie       `yaml:"cookie"`
+	Session      Session      `yaml:"session"`
+	Provisioning Provisioning `yaml:"provisioning"`
+	Clients      Clients      `yaml:"clients"`
+	Builds       Builds       `yaml:"builds"`
+	OAuth        OAuth        `yaml:"oauth"`
+	Access       Access       `yaml:"access"`
+	Invitations  Invitations  `yaml:"invitations"`
+	GitHub       GitHub       `yaml:"github"`
+	GitLab       GitLab       `yaml:"gitlab"`
+	OIDC         OIDC         `yaml:"oidc"`
+	Kubernetes   Kubernetes   `yaml:"kubernetes"`
+}
+
+// Service contains settings of HTTP server
+type Service struct {
+	Host            string        `yaml:"host" env:"SERVICE_HOST" flag:"host" usage:"host listen by the service"`
+	Port            string        `yaml:"port" env:"SERVICE_PORT" flag:"port" usage:"port listen by the service"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"time to wait for in-flight requests during shutdown"`
+
+	// PublicURL is the base URL the users open the site with, the links given to the clients
+	// outside of the browser (e.g. the verification URI of the CLI) are built from it, not from the request
+	PublicURL string `yaml:"publicURL" env:"PUBLIC_URL" flag:"public-url" usage:"base URL of the site as the users see it"`
+
+	// ShutdownDrain is the time the service keeps serving after /healthz starts failing,
+	// so the readiness probe takes the pod out of the endpoints before the connections are refused
+	ShutdownDrain time.Duration `yaml:"shutdownDrain" env:"SHUTDOWN_DRAIN" flag:"shutdown-drain" usage:"time to keep serving after readiness starts failing during shutdown"`
+}
+
+// Templates contains settings of HTML templates of the pages
+type Templates struct {
+	Dir string `yaml:"dir" env:"TEMPLATES_DIR" flag:"templates-dir" usage:"directory with the templates of the pages"`
+
+	// Watch is intended for development: the changed templates are reloaded without restarting the service
+	Watch bool `yaml:"watch" env:"TEMPLATES_WATCH" flag:"templates-watch" usage:"reload the templates when they are changed (development mode)"`
+}
+
+// Log contains logger settings
+type Log struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"log level: debug, info, warning, error"`
+	Format string `yaml:"format" env:"LOG_FORMAT" flag:"log-format" usage:"log format: text or json"`
+}
+
+// DB contains database connection settings.
+// DSN has priority over the separate connection parameters.
+type DB struct {
+	DSN      string `yaml:"dsn" env:"DB_CONNECTION_STRING" flag:"db-dsn" usage:"database connection string"`
+	Host     string `yaml:"host" env:"DB_HOST" flag:"db-host" usage:"database host"`
+	Port     string `yaml:"port" env:"DB_PORT" flag:"db-port" usage:"database port"`
+	User     string `yaml:"user" env:"UIDB_USER" flag:"db-user" usage:"database user"`
+	Password string `yaml:"password" env:"UIDB_PASSWORD" flag:"db-password" usage:"database password"`
+	Name     string `yaml:"name" env:"UIDB_NAME" flag:"db-name" usage:"database name"`
+	SSLMode  string `yaml:"sslMode" env:"DB_SSL_MODE" flag:"db-ssl-mode" usage:"database SSL mode"`
+
+	// AutoMigrate defines if pending migrations should be applied on startup,
+	// otherwise the service refuses to start when the schema is behind
+	AutoMigrate bool `yaml:"autoMigrate" env:"DB_AUTO_MIGRATE" flag:"db-auto-migrate" usage:"apply pending migrations on startup"`
+}
+
+// Cookie contains settings of session cookies
+type Cookie struct {
+	Name      string        `yaml:"name" env:"COOKIE_NAME" flag:"cookie-name" usage:"name of the session cookie"`
+	AllowHTTP bool          `yaml:"allowHTTP" env:"COOKIE_ALLOW_HTTP" flag:"cookie-allow-http" usage:"send session cookie over plain HTTP"`
+	MaxAge    time.Duration `yaml:"maxAge" env:"COOKIE_MAX_AGE" flag:"cookie-max-age" usage:"max age of the session cookie"`
+	Path      string        `yaml:"path" env:"COOKIE_PATH" flag:"cookie-path" usage:"path of the session cookie"`
+}
+
+// Session contains settings of server-side session lifetime
+type Session struct {
+	IdleTimeout   time.Duration `yaml:"idleTimeout" env:"SESSION_IDLE_TIMEOUT" flag:"session-idle-timeout" usage:"time the session expires after the last access"`
+	MaxAge        time.Duration `yaml:"maxAge" env:"SESSION_MAX_AGE" flag:"session-max-age" usage:"time the session expires after creation"`
+	SweepInterval time.Duration `yaml:"sweepInterval" env:"SESSION_SWEEP_INTERVAL" flag:"session-sweep-interval" usage:"how often the expired sessions are deleted"`
+}
+
+// Builds contains settings of the build pages
+type Builds struct {
+	// TailInterval defines how often github-integration is polled for the logs of the running builds
+	TailInterval time.Duration `yaml:"tailInterval" env:"BUILDS_TAIL_INTERVAL" flag:"builds-tail-interval" usage:"how often the logs of the running builds are polled"`
+
+	// History enables the build history of the users, github-integration must support listing the builds
+	History bool `yaml:"history" env:"BUILDS_HISTORY" flag:"builds-history" usage:"show the build history, github-integration must support listing the builds"`
+
+	// Public contains the repositories which builds are shown to everyone, even without signing in
+	Public []string `yaml:"public" env:"BUILDS_PUBLIC" flag:"builds-public" usage:"comma-separated repositories which builds are public, as username/repository"`
+}
+
+// Provisioning contains settings of the jobs creating users' environments in Kubernetes
+type Provisioning struct {
+	Workers      int           `yaml:"workers" env:"PROVISIONING_WORKERS" flag:"provisioning-workers" usage:"number of provisioning jobs run concurrently"`
+	PollInterval time.Duration `yaml:"pollInterval" env:"PROVISIONING_POLL_INTERVAL" flag:"provisioning-poll-interval" usage:"how often the provisioning jobs are looked for"`
+	MaxAttempts  int           `yaml:"maxAttempts" env:"PROVISIONING_MAX_ATTEMPTS" flag:"provisioning-max-attempts" usage:"number of attempts after which the provisioning job is failed"`
+	MinBackoff   time.Duration `yaml:"minBackoff" env:"PROVISIONING_MIN_BACKOFF" flag:"provisioning-min-backoff" usage:"delay before the first retry of the provisioning job"`
+	MaxBackoff   time.Duration `yaml:"maxBackoff" env:"PROVISIONING_MAX_BACKOFF" flag:"provisioning-max-backoff" usage:"max delay between the retries of the provisioning job"`
+
+	// StatusInterval defines how often the status is checked for the users waiting for their environments
+	StatusInterval time.Duration `yaml:"statusInterval" env:"PROVISIONING_STATUS_INTERVAL" flag:"provisioning-status-interval" usage:"how often the provisioning status is pushed to the home page"`
+}
+
+// Clients contains base URLs of the services used by ui
+type Clients struct {
+	UserManagerURL       string `yaml:"userManagerURL" env:"USERMAN_BASE_URL" flag:"userman-url" usage:"base URL of user-manager service"`
+	GitHubIntegrationURL string `yaml:"githubIntegrationURL" env:"GHINT_BASE_URL" flag:"ghint-url" usage:"base URL of github-integration service"`
+}
+
+// OAuth contains settings common for all OAuth providers
+type OAuth struct {
+	// StateTTL is the time given to the user to complete authorization on the provider's side
+	StateTTL time.Duration `yaml:"stateTTL" env:"OAUTH_STATE_TTL" flag:"oauth-state-ttl" usage:"lifetime of OAuth state cookie"`
+
+	// DeviceCodeTTL is the time given to the user to approve the sign in of the device (the CLI)
+	DeviceCodeTTL time.Duration `yaml:"deviceCodeTTL" env:"OAUTH_DEVICE_CODE_TTL" flag:"oauth-device-code-ttl" usage:"lifetime of device and user codes"`
+
+	// DevicePollInterval is the minimum time between the requests of the device waiting for the approval
+	DevicePollInterval time.Duration `yaml:"devicePollInterval" env:"OAUTH_DEVICE_POLL_INTERVAL" flag:"oauth-device-poll-interval" usage:"minimum interval of device polling"`
+}
+
+// Access contains the rules restricting sign in, everyone is allowed to sign in by default
+type Access struct {
+	Allow  []string `yaml:"allow" env:"ACCESS_ALLOW" flag:"access-allow" usage:"comma-separated logins allowed to sign in, as source/login"`
+	Block  []string `yaml:"block" env:"ACCESS_BLOCK" flag:"access-block" usage:"comma-separated logins not allowed to sign in, as source/login"`
+	Groups []string `yaml:"groups" env:"ACCESS_GROUPS" flag:"access-groups" usage:"comma-separated groups allowed to sign in as source/group: GitHub organizations (github/org), teams (github/org/team) or OpenID Connect groups"`
+
+	// Admins and Instructors see the builds of all the users
+	Admins      []string `yaml:"admins" env:"ACCESS_ADMINS" flag:"access-admins" usage:"comma-separated logins of the admins, as source/login"`
+	Instructors []string `yaml:"instructors" env:"ACCESS_INSTRUCTORS" flag:"access-instructors" usage:"comma-separated logins of the instructors, as source/login"`
+}
+
+// Invitations contains settings of workshop enrollment
+type Invitations struct {
+	// Required defines if the users have to present an invitation code to enroll,
+	// the codes are managed with "invitations" subcommand
+	Required bool `yaml:"required" env:"INVITATIONS_REQUIRED" flag:"invitations-required" usage:"require invitation code to enroll"`
+}
+
+// GitHub contains settings of GitHub OAuth application, GitHub sign in is enabled if client ID is set
+type GitHub struct {
+	ClientID     string `yaml:"clientID" env:"GITHUB_CLIENT_ID" flag:"github-client-id" usage:"client ID of GitHub OAuth application"`
+	ClientSecret string `yaml:"clientSecret" env:"GITHUB_CLIENT_SECRET" flag:"github-client-secret" usage:"client secret of GitHub OAuth application"`
+
+	// OAuthState is a secret key to sign per-request OAuth states bound to the browser
+	OAuthState string `yaml:"oauthState" env:"GITHUB_OAUTH_STATE" flag:"github-oauth-state" usage:"secret key to sign OAuth state cookies"`
+	PKCE       bool   `yaml:"pkce" env:"GITHUB_PKCE" flag:"github-pkce" usage:"use PKCE for GitHub authorization"`
+
+	// AuthURL, TokenURL and APIURL are set for GitHub Enterprise Server, github.com is used by default
+	AuthURL  string `yaml:"authURL" env:"GITHUB_AUTH_URL" flag:"github-auth-url" usage:"authorization URL of GitHub Enterprise"`
+	TokenURL string `yaml:"tokenURL" env:"GITHUB_TOKEN_URL" flag:"github-token-url" usage:"token URL of GitHub Enterprise"`
+	APIURL   string `yaml:"apiURL" env:"GITHUB_API_URL" flag:"github-api-url" usage:"API base URL of GitHub Enterprise"`
+}
+
+// GitLab contains settings of GitLab OAuth application, GitLab sign in is enabled if client ID is set
+type GitLab struct {
+	URL          string `yaml:"url" env:"GITLAB_URL" flag:"gitlab-url" usage:"URL of GitLab instance"`
+	ClientID     string `yaml:"clientID" env:"GITLAB_CLIENT_ID" flag:"gitlab-client-id" usage:"application ID of GitLab OAuth application"`
+	ClientSecret string `yaml:"clientSecret" env:"GITLAB_CLIENT_SECRET" flag:"gitlab-client-secret" usage:"secret of GitLab OAuth application"`
+	RedirectURL  string `yaml:"redirectURL" env:"GITLAB_REDIRECT_URL" flag:"gitlab-redirect-url" usage:"callback URL of GitLab OAuth application"`
+	PKCE         bool   `yaml:"pkce" env:"GITLAB_PKCE" flag:"gitlab-pkce" usage:"use PKCE for GitLab authorization"`
+}
+
+// OIDC contains settings of OpenID Connect provider, OpenID Connect sign in is enabled if issuer is set
+type OIDC struct {
+	Title        string   `yaml:"title" env:"OIDC_TITLE" flag:"oidc-title" usage:"name of OpenID Connect provider shown to the users"`
+	Issuer       string   `yaml:"issuer" env:"OIDC_ISSUER" flag:"oidc-issuer" usage:"issuer URL of OpenID Connect provider"`
+	ClientID     string   `yaml:"clientID" env:"OIDC_CLIENT_ID" flag:"oidc-client-id" usage:"client ID registered in OpenID Connect provider"`
+	ClientSecret string   `yaml:"clientSecret" env:"OIDC_CLIENT_SECRET" flag:"oidc-client-secret" usage:"client secret registered in OpenID Connect provider"`
+	RedirectURL  string   `yaml:"redirectURL" env:"OIDC_REDIRECT_URL" flag:"oidc-redirect-url" usage:"callback URL registered in OpenID Connect provider"`
+	Scopes       []string `yaml:"scopes" env:"OIDC_SCOPES" flag:"oidc-scopes" usage:"comma-separated scopes requested in addition to openid"`
+	Claim        string   `yaml:"claim" env:"OIDC_CLAIM" flag:"oidc-claim" usage:"claim of ID token used as the user's login"`
+	PKCE         bool     `yaml:"pkce" env:"OIDC_PKCE" flag:"oidc-pkce" usage:"use PKCE for OpenID Connect authorization"`
+}
+
+// Kubernetes contains settings of Kubernetes cluster the users are working with
+type Kubernetes struct {
+	ClusterName string `yaml:"clusterName" env:"K8S_CLUSTER_NAME" flag:"k8s-cluster-name" usage:"cluster name used in kubeconfig files"`
+	APIServer   string `yaml:"apiServer" env:"K8S_API_SERVER" flag:"k8s-api-server" usage:"URL of Kubernetes API server used in kubeconfig files"`
+	GuestToken  string `yaml:"guestToken" env:"K8S_GUEST_TOKEN" flag:"k8s-guest-token" usage:"token to reach Kubernetes for guests"`
+}
+
+// minStateSecretLength is the minimal length of the key to sign OAuth states
+const minStateSecretLength = 16
+
+// Default returns config with default values
+func Default() *Config {
+	return &Config{
+		ServiceDiscovery: true,
+		Service: Service{
+			Host:            "0.0.0.0",
+			Port:            "8080",
+			ShutdownTimeout: 25 * time.Second,
+			ShutdownDrain:   10 * time.Second,
+		},
+		Templates: Templates{
+			Dir: "templates",
+		},
+		Log: Log{
+			Level:  "debug",
+			Format: "text",
+		},
+		DB: DB{
+			SSLMode: "disable",
+		},
+		Cookie: Cookie{
+			Name:      "k8s-community-session-id",
+			AllowHTTP: true,
+			MaxAge:    48 * time.Hour,
+			Path:      "/",
+		},
+		Session: Session{
+			IdleTimeout:   12 * time.Hour,
+			MaxAge:        48 * time.Hour,
+			SweepInterval: 10 * time.Minute,
+		},
+		Provisioning: Provisioning{
+			Workers:      2,
+			PollInterval: 5 * time.Second,
+			MaxAttempts:  10,
+			MinBackoff:   5 * time.Second,
+			MaxBackoff:   10 * time.Minute,
+
+			StatusInterval: 2 * time.Second,
+		},
+		Builds: Builds{
+			TailInterval: 2 * time.Second,
+		},
+		OAuth: OAuth{
+			StateTTL:           10 * time.Minute,
+			DeviceCodeTTL:      10 * time.Minute,
+			DevicePollInterval: 5 * time.Second,
+		},
+		GitLab: GitLab{
+			URL: "https://gitlab.com",
+		},
+		OIDC: OIDC{
+			Title:  "OpenID Connect",
+			Scopes: []string{"profile"},
+			Claim:  "preferred_username",
+		},
+	}
+}
+
+// HostPort returns the address listen by the service
+func (c *Config) HostPort() string {
+	return fmt.Sprintf("%s:%s", c.Service.Host, c.Service.Port)
+}
+
+// DataSource returns database connection string
+func (c *Config) DataSource() string {
+	if c.DB.DSN != "" {
+		return c.DB.DSN
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.DB.User, c.DB.Password),
+		Host:     fmt.Sprintf("%s:%s", c.DB.Host, c.DB.Port),
+		Path:     c.DB.Name,
+		RawQuery: url.Values{"sslmode": {c.DB.SSLMode}}.Encode(),
+	}
+
+	return dsn.String()
+}
+
+// discover fills the addresses which aren't defined explicitly using service discovery
+func (c *Config) discover() {
+	if !c.ServiceDiscovery || c.Namespace == "" {
+		return
+	}
+
+	if c.Clients.UserManagerURL == "" {
+		c.Clients.UserManagerURL = fmt.Sprintf("http://user-manager.%s:80", c.Namespace)
+	}
+	if c.Clients.GitHubIntegrationURL == "" {
+		c.Clients.GitHubIntegrationURL = fmt.Sprintf("http://github-integration.%s:80", c.Namespace)
+	}
+	if c.DB.DSN == "" && c.DB.Host == "" {
+		c.DB.Host = fmt.Sprintf("uidb.%s", c.Namespace)
+	}
+	if c.DB.DSN == "" && c.DB.Port == "" {
+		c.DB.Port = "5432"
+	}
+}
+
+// Validate checks all the settings and reports all the problems together
+func (c *Config) Validate() error {
+	errs := c.validateDB()
+
+	required := func(value, name string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s must be set", name))
+		}
+	}
+	validURL := func(value, name string) {
+		if value == "" {
+			return
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s must be an absolute URL, got %q", name, value))
+		}
+	}
+
+	required(c.Service.Host, "service host (SERVICE_HOST)")
+	required(c.Service.Port, "service port (SERVICE_PORT)")
+	required(c.Service.PublicURL, "public URL (PUBLIC_URL)")
+	validURL(c.Service.PublicURL, "public URL (PUBLIC_URL)")
+	required(c.Templates.Dir, "templates directory (TEMPLATES_DIR)")
+	if c.Service.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown timeout (SHUTDOWN_TIMEOUT) must be positive"))
+	}
+	if c.Service.ShutdownDrain < 0 {
+		errs = append(errs, fmt.Errorf("shutdown drain (SHUTDOWN_DRAIN) must not be negative"))
+	}
+
+	required(c.Cookie.Name, "cookie name (COOKIE_NAME)")
+	if c.Cookie.MaxAge <= 0 {
+		errs = append(errs, fmt.Errorf("cookie max age (COOKIE_MAX_AGE) must be positive"))
+	}
+
+	if c.Session.IdleTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("session idle timeout (SESSION_IDLE_TIMEOUT) must be positive"))
+	}
+	if c.Session.MaxAge <= 0 {
+		errs = append(errs, fmt.Errorf("session max age (SESSION_MAX_AGE) must be positive"))
+	}
+	if c.Session.SweepInterval <= 0 {
+		errs = append(errs, fmt.Errorf("session sweep interval (SESSION_SWEEP_INTERVAL) must be positive"))
+	}
+
+	if c.Provisioning.Workers <= 0 {
+		errs = append(errs, fmt.Errorf("provisioning workers (PROVISIONING_WORKERS) must be positive"))
+	}
+	if c.Provisioning.PollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("provisioning poll interval (PROVISIONING_POLL_INTERVAL) must be positive"))
+	}
+	if c.Provisioning.MaxAttempts <= 0 {
+		errs = append(errs, fmt.Errorf("provisioning max attempts (PROVISIONING_MAX_ATTEMPTS) must be positive"))
+	}
+	if c.Provisioning.MinBackoff <= 0 || c.Provisioning.MaxBackoff < c.Provisioning.MinBackoff {
+		errs = append(errs, fmt.Errorf(
+			"provisioning backoff (PROVISIONING_MIN_BACKOFF, PROVISIONING_MAX_BACKOFF) must be positive and min <= max",
+		))
+	}
+
+	if c.Provisioning.StatusInterval <= 0 {
+		errs = append(errs, fmt.Errorf("provisioning status interval (PROVISIONING_STATUS_INTERVAL) must be positive"))
+	}
+	if c.Builds.TailInterval <= 0 {
+		errs = append(errs, fmt.Errorf("builds tail interval (BUILDS_TAIL_INTERVAL) must be positive"))
+	}
+	for _, entry := range c.Builds.Public {
+		if err := access.ValidRepository(entry); err != nil {
+			errs = append(errs, fmt.Errorf("public builds (BUILDS_PUBLIC): %v", err))
+		}
+	}
+
+	required(c.Clients.UserManagerURL, "user-manager URL (USERMAN_BASE_URL)")
+	validURL(c.Clients.UserManagerURL, "user-manager URL (USERMAN_BASE_URL)")
+	required(c.Clients.GitHubIntegrationURL, "github-integration URL (GHINT_BASE_URL)")
+	validURL(c.Clients.GitHubIntegrationURL, "github-integration URL (GHINT_BASE_URL)")
+
+	if c.OAuth.StateTTL <= 0 {
+		errs = append(errs, fmt.Errorf("OAuth state TTL (OAUTH_STATE_TTL) must be positive"))
+	}
+	if c.OAuth.DeviceCodeTTL <= 0 {
+		errs = append(errs, fmt.Errorf("device code TTL (OAUTH_DEVICE_CODE_TTL) must be positive"))
+	}
+	if c.OAuth.DevicePollInterval < time.Second {
+		errs = append(errs, fmt.Errorf("device poll interval (OAUTH_DEVICE_POLL_INTERVAL) must be at least 1s"))
+	}
+
+	if c.GitHub.ClientID == "" && c.GitLab.ClientID == "" && c.OIDC.Issuer == "" {
+		errs = append(errs, fmt.Errorf(
+			"at least one sign in provider must be set (GITHUB_CLIENT_ID, GITLAB_CLIENT_ID, OIDC_ISSUER)",
+		))
+	}
+	if c.GitHub.ClientID != "" {
+		required(c.GitHub.ClientSecret, "GitHub client secret (GITHUB_CLIENT_SECRET)")
+		validURL(c.GitHub.AuthURL, "GitHub auth URL (GITHUB_AUTH_URL)")
+		validURL(c.GitHub.TokenURL, "GitHub token URL (GITHUB_TOKEN_URL)")
+		validURL(c.GitHub.APIURL, "GitHub API URL (GITHUB_API_URL)")
+		if c.GitHub.AuthURL != "" || c.GitHub.TokenURL != "" || c.GitHub.APIURL != "" {
+			required(c.GitHub.AuthURL, "GitHub auth URL (GITHUB_AUTH_URL) for GitHub Enterprise")
+			required(c.GitHub.TokenURL, "GitHub token URL (GITHUB_TOKEN_URL) for GitHub Enterprise")
+			required(c.GitHub.APIURL, "GitHub API URL (GITHUB_API_URL) for GitHub Enterprise")
+		}
+	}
+	if c.GitLab.ClientID != "" {
+		required(c.GitLab.ClientSecret, "GitLab client secret (GITLAB_CLIENT_SECRET)")
+		required(c.GitLab.URL, "GitLab URL (GITLAB_URL)")
+		validURL(c.GitLab.URL, "GitLab URL (GITLAB_URL)")
+		required(c.GitLab.RedirectURL, "GitLab redirect URL (GITLAB_REDIRECT_URL)")
+		validURL(c.GitLab.RedirectURL, "GitLab redirect URL (GITLAB_REDIRECT_URL)")
+	}
+	if c.OIDC.Issuer != "" {
+		validURL(c.OIDC.Issuer, "OpenID Connect issuer (OIDC_ISSUER)")
+		required(c.OIDC.Title, "OpenID Connect title (OIDC_TITLE)")
+		required(c.OIDC.ClientID, "OpenID Connect client ID (OIDC_CLIENT_ID)")
+		required(c.OIDC.ClientSecret, "OpenID Connect client secret (OIDC_CLIENT_SECRET)")
+		required(c.OIDC.RedirectURL, "OpenID Connect redirect URL (OIDC_REDIRECT_URL)")
+		validURL(c.OIDC.RedirectURL, "OpenID Connect redirect URL (OIDC_REDIRECT_URL)")
+		required(c.OIDC.Claim, "OpenID Connect claim (OIDC_CLAIM)")
+	}
+	if len(c.GitHub.OAuthState) < minStateSecretLength {
+		errs = append(errs, fmt.Errorf(
+			"GitHub OAuth state secret (GITHUB_OAUTH_STATE) must be at least %d characters long", minStateSecretLength,
+		))
+	}
+
+	for _, list := range []struct {
+		name    string
+		entries []string
+	}{
+		{"allowed logins (ACCESS_ALLOW)", c.Access.Allow},
+		{"blocked logins (ACCESS_BLOCK)", c.Access.Block},
+		{"admins (ACCESS_ADMINS)", c.Access.Admins},
+		{"instructors (ACCESS_INSTRUCTORS)", c.Access.Instructors},
+	} {
+		for _, entry := range list.entries {
+			if _, _, err := access.ParseLogin(entry); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", list.name, err))
+			}
+		}
+	}
+
+	for _, entry := range c.Access.Groups {
+		if err := access.ValidGroup(entry); err != nil {
+			errs = append(errs, fmt.Errorf("allowed groups (ACCESS_GROUPS): %v", err))
+		}
+	}
+
+	required(c.Kubernetes.ClusterName, "Kubernetes cluster name (K8S_CLUSTER_NAME)")
+	required(c.Kubernetes.APIServer, "Kubernetes API server (K8S_API_SERVER)")
+	validURL(c.Kubernetes.APIServer, "Kubernetes API server (K8S_API_SERVER)")
+	required(c.Kubernetes.GuestToken, "Kubernetes guest token (K8S_GUEST_TOKEN)")
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// ValidateDB checks only the settings required to connect to the database
+func (c *Config) ValidateDB() error {
+	if errs := c.validateDB(); len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (c *Config) validateDB() Errors {
+	var errs Errors
+
+	required := func(value, name string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s must be set", name))
+		}
+	}
+
+	if c.ServiceDiscovery {
+		required(c.Namespace, "namespace (NAMESPACE) when service discovery is enabled")
+	}
+
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log level (LOG_LEVEL): %v", err))
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		errs = append(errs, fmt.Errorf("log format (LOG_FORMAT) must be text or json, got %q", c.Log.Format))
+	}
+
+	if c.DB.DSN == "" {
+		required(c.DB.Host, "database host (DB_HOST)")
+		required(c.DB.Port, "database port (DB_PORT)")
+		required(c.DB.User, "database user (UIDB_USER)")
+		required(c.DB.Password, "database password (UIDB_PASSWORD)")
+		required(c.DB.Name, "database name (UIDB_NAME)")
+	}
+
+	return errs
+}
+
+// Errors is a list of configuration problems
+type Errors []error
+
+// Error implements error interface
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("invalid configuration: %s", strings.Join(messages, "; "))
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Load loads the config in the order of precedence:
+// defaults, config file, environment variables, flags.
+// The variables and the flags which are set, but empty, reset the settings to the empty (zero) values.
+// Path to the config file is defined by -config flag or UI_CONFIG environment variable.
+// All the problems are reported together.
+func Load(name string, args []string) (*Config, error) {
+	cfg, rest, err := load(name, args, (*Config).Validate)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", rest)
+	}
+
+	return cfg, nil
+}
+
+// LoadDB loads the config like Load, but validates only the database settings.
+// The arguments left after the flags are returned.
+func LoadDB(name string, args []string) (*Config, []string, error) {
+	return load(name, args, (*Config).ValidateDB)
+}
+
+func load(name string, args []string, validate func(*Config) error) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("UI_CONFIG"), "path to config file (YAML)")
+	flags := make(map[string]*flagValue)
+	walk(reflect.ValueOf(cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		name := field.Tag.Get("flag")
+		if name == "" {
+			return
+		}
+		flags[name] = &flagValue{raw: fmt.Sprint(value.Interface()), isBool: value.Kind() == reflect.Bool}
+		fs.Var(flags[name], name, field.Tag.Get("usage"))
+	})
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configPath != "" {
+		data, err := ioutil.ReadFile(*configPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("couldn't read config file: %v", err)
+		}
+		if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+			return nil, nil, fmt.Errorf("couldn't parse config file %s: %v", *configPath, err)
+		}
+	}
+
+	var errs Errors
+
+	walk(reflect.ValueOf(cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		name := field.Tag.Get("env")
+		if name == "" {
+			return
+		}
+		if raw, ok := os.LookupEnv(name); ok {
+			if err := set(value, raw); err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s: %v", name, err))
+			}
+		}
+	})
+
+	walk(reflect.ValueOf(cfg).Elem(), func(field reflect.StructField, value reflect.Value) {
+		name := field.Tag.Get("flag")
+		if name == "" || !flags[name].set {
+			return
+		}
+		if err := set(value, flags[name].raw); err != nil {
+			errs = append(errs, fmt.Errorf("flag -%s: %v", name, err))
+		}
+	})
+
+	cfg.discover()
+
+	if err := validate(cfg); err != nil {
+		errs = append(errs, err.(Errors)...)
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// walk calls fn for each leaf field of the struct
+func walk(v reflect.Value, fn func(field reflect.StructField, value reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			walk(v.Field(i), fn)
+			continue
+		}
+		fn(field, v.Field(i))
+	}
+}
+
+// set parses raw value according to the field type, the empty value resets the field to the zero value
+func set(value reflect.Value, raw string) error {
+	if raw == "" {
+		value.Set(reflect.Zero(value.Type()))
+		return nil
+	}
+
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(i)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+
+	return nil
+}
+
+// flagValue keeps the raw flag value to apply it after the other config sources
+type flagValue struct {
+	raw    string
+	set    bool
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.raw
+}
+
+func (f *flagValue) Set(raw string) error {
+	f.raw = raw
+	f.set = true
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
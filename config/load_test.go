@@ -0,0 +1,150 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setenv sets the environment variables and returns the function restoring them
+func setenv(t *testing.T, env map[string]string) func() {
+	old := make(map[string]*string)
+	for name, value := range env {
+		if v, ok := os.LookupEnv(name); ok {
+			old[name] = &v
+		} else {
+			old[name] = nil
+		}
+		if err := os.Setenv(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return func() {
+		for name, value := range old {
+			if value == nil {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, *value)
+			}
+		}
+	}
+}
+
+// unsetenv unsets the environment variables and returns the function restoring them
+func unsetenv(t *testing.T, names ...string) func() {
+	old := make(map[string]string)
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok {
+			old[name] = v
+		}
+		if err := os.Unsetenv(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return func() {
+		for name, value := range old {
+			os.Setenv(name, value)
+		}
+	}
+}
+
+// writeConfig writes the config file to the temporary directory and returns its path
+func writeConfig(t *testing.T, dir, content string) string {
+	path := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeConfig(t, dir, `
+serviceDiscovery: false
+service:
+  port: "8081"
+  shutdownTimeout: 5s
+  shutdownDrain: 3s
+templates:
+  dir: /templates
+log:
+  level: info
+  format: json
+db:
+  dsn: postgres://ui@localhost/ui
+`)
+
+	defer unsetenv(t, "SHUTDOWN_TIMEOUT", "SERVICE_PORT")()
+	defer setenv(t, map[string]string{
+		"LOG_LEVEL":      "warning",
+		"LOG_FORMAT":     "text",
+		"BUILDS_PUBLIC":  "k8s-community/ui, k8s-community/user-manager",
+		"TEMPLATES_DIR":  "",
+		"SHUTDOWN_DRAIN": "",
+	})()
+
+	cfg, rest, err := LoadDB("ui", []string{"-config", path, "-log-level", "error", "migrate", "up"})
+	if err != nil {
+		t.Fatalf("LoadDB() = %v", err)
+	}
+
+	if cfg.Service.Host != "0.0.0.0" {
+		t.Errorf("host = %s, want the default 0.0.0.0", cfg.Service.Host)
+	}
+	if cfg.Service.Port != "8081" || cfg.Service.ShutdownTimeout != 5*time.Second {
+		t.Errorf("port = %s, shutdown timeout = %s, want 8081 and 5s of the file", cfg.Service.Port, cfg.Service.ShutdownTimeout)
+	}
+	if cfg.Templates.Dir != "" || cfg.Service.ShutdownDrain != 0 {
+		t.Errorf("templates dir = %q, shutdown drain = %s, want them reset by the empty variables",
+			cfg.Templates.Dir, cfg.Service.ShutdownDrain)
+	}
+	if cfg.Log.Format != "text" {
+		t.Errorf("log format = %s, want text of the environment", cfg.Log.Format)
+	}
+	if cfg.Log.Level != "error" {
+		t.Errorf("log level = %s, want error of the flag", cfg.Log.Level)
+	}
+	if len(cfg.Builds.Public) != 2 || cfg.Builds.Public[1] != "k8s-community/user-manager" {
+		t.Errorf("public builds = %q, want k8s-community/ui and k8s-community/user-manager", cfg.Builds.Public)
+	}
+	if len(rest) != 2 || rest[0] != "migrate" || rest[1] != "up" {
+		t.Errorf("arguments = %q, want migrate up", rest)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := writeConfig(t, dir, "service:\n  prot: 8081\n")
+	if _, _, err = LoadDB("ui", []string{"-config", path}); err == nil {
+		t.Error("LoadDB() with unknown key in the file succeeded, want error")
+	}
+
+	path = writeConfig(t, dir, "serviceDiscovery: false\ndb:\n  dsn: postgres://ui@localhost/ui\n")
+	defer setenv(t, map[string]string{"SHUTDOWN_TIMEOUT": "soon"})()
+
+	_, _, err = LoadDB("ui", []string{"-config", path, "-provisioning-workers", "many"})
+	if err == nil {
+		t.Fatal("LoadDB() with wrong values succeeded, want error")
+	}
+	for _, want := range []string{"SHUTDOWN_TIMEOUT", "-provisioning-workers"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadDB() = %v, want the problem of %s reported", err, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Package dbtest provides the database for the tests of the packages working with it
+package dbtest
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	_ "github.com/lib/pq" // postgresql driver
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+
+	"github.com/k8s-community/ui/db/migrations"
+)
+
+// DB returns the database given by UI_TEST_DB connection string with the migrations applied,
+// the test is skipped if it isn't set
+func DB(t *testing.T) *reform.DB {
+	dsn := os.Getenv("UI_TEST_DB")
+	if dsn == "" {
+		t.Skip("UI_TEST_DB is not set")
+	}
+
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := reform.NewDB(conn, postgresql.Dialect, nil)
+
+	migrator, err := migrations.New(db, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = migrator.Up(); err != nil {
+		t.Fatalf("couldn't apply migrations: %v", err)
+	}
+
+	return db
+}
+
+// Login returns a login unique for the test run, so the runs don't affect each other
+func Login(name string) string {
+	return fmt.Sprintf("%s-%d", name, time.Now().UnixNano())
+}
@@ -1,4 +1,5 @@
-CREATE TABLE users (
+-- The baseline schema: IF NOT EXISTS keeps databases initialized by the former db/init.sql working
+CREATE TABLE IF NOT EXISTS users (
   id          SERIAL PRIMARY KEY,
   source      VARCHAR(128) NOT NULL,
   name        VARCHAR(128) NOT NULL,
@@ -0,0 +1,12 @@
+ALTER TABLE users
+  ADD COLUMN session_id VARCHAR(256) DEFAULT NULL UNIQUE,
+  ADD COLUMN session_data TEXT DEFAULT NULL;
+
+-- Only one session per user can be kept, the most recently accessed one
+UPDATE users SET session_id = s.session_id, session_data = s.data
+  FROM (
+    SELECT DISTINCT ON (user_id) user_id, session_id, data FROM sessions ORDER BY user_id, last_access_at DESC
+  ) s
+  WHERE users.id = s.user_id;
+
+DROP TABLE sessions;
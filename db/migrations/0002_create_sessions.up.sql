@@ -0,0 +1,21 @@
+CREATE TABLE sessions (
+  id              SERIAL PRIMARY KEY,
+  session_id      VARCHAR(256) NOT NULL UNIQUE,
+  user_id         INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  data            TEXT NOT NULL DEFAULT '{}',
+
+  user_agent      TEXT DEFAULT NULL,
+  ip              VARCHAR(64) DEFAULT NULL,
+
+  created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+  last_access_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+  expires_at      TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX sessions_user_id ON sessions (user_id);
+
+-- Keep the sessions stored in users table alive
+INSERT INTO sessions (session_id, user_id, data)
+  SELECT session_id, id, COALESCE(session_data, '{}') FROM users WHERE session_id IS NOT NULL;
+
+ALTER TABLE users DROP COLUMN session_id, DROP COLUMN session_data;
@@ -0,0 +1,16 @@
+-- One job per user: every sign in queues the job again once it's finished
+CREATE TABLE provisioning_jobs (
+  id          SERIAL PRIMARY KEY,
+  user_id     INTEGER NOT NULL UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+  state       VARCHAR(16) NOT NULL DEFAULT 'queued',
+  attempts    INTEGER NOT NULL DEFAULT 0,
+  last_error  TEXT DEFAULT NULL,
+
+  -- the time the queued job should be (re)tried at or the lease of the running job expires at
+  run_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX provisioning_jobs_run_at ON provisioning_jobs (run_at) WHERE state IN ('queued', 'running');
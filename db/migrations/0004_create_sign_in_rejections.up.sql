@@ -0,0 +1,13 @@
+CREATE TABLE sign_in_rejections (
+  id          SERIAL PRIMARY KEY,
+  source      VARCHAR(128) NOT NULL,
+  name        VARCHAR(128) NOT NULL,
+  reason      TEXT NOT NULL,
+
+  user_agent  TEXT DEFAULT NULL,
+  ip          VARCHAR(64) DEFAULT NULL,
+
+  created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX sign_in_rejections_created_at ON sign_in_rejections (created_at);
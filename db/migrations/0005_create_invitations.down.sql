@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS invitation_redemptions;
+DROP TABLE IF EXISTS invitation_codes;
@@ -0,0 +1,22 @@
+CREATE TABLE invitation_codes (
+  id          SERIAL PRIMARY KEY,
+  code        VARCHAR(64) NOT NULL UNIQUE,
+  workshop    VARCHAR(128) DEFAULT NULL,
+  max_uses    INTEGER NOT NULL DEFAULT 1,
+  uses        INTEGER NOT NULL DEFAULT 0,
+  expires_at  TIMESTAMP DEFAULT NULL,
+  created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The users are identified by source and name since the code is redeemed before the user is created
+CREATE TABLE invitation_redemptions (
+  id          SERIAL PRIMARY KEY,
+  code_id     INTEGER NOT NULL REFERENCES invitation_codes (id) ON DELETE CASCADE,
+  source      VARCHAR(128) NOT NULL,
+  name        VARCHAR(128) NOT NULL,
+  created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  CONSTRAINT u_code_source_name UNIQUE (code_id, source, name)
+);
+
+CREATE INDEX invitation_redemptions_source_name ON invitation_redemptions (source, name);
@@ -0,0 +1,12 @@
+-- Personal access tokens of the API clients, only SHA-256 hashes of the tokens are stored
+CREATE TABLE access_tokens (
+  id            SERIAL PRIMARY KEY,
+  user_id       INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name          VARCHAR(128) NOT NULL,
+  token_hash    VARCHAR(64) NOT NULL UNIQUE,
+  scopes        VARCHAR(256) NOT NULL DEFAULT '',
+  last_used_at  TIMESTAMP DEFAULT NULL,
+  created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX access_tokens_user_id ON access_tokens (user_id);
@@ -0,0 +1,13 @@
+-- Pending sign ins of the devices (the CLI) with the device authorization grant (RFC 8628),
+-- only SHA-256 hashes of the device codes are stored
+CREATE TABLE device_authorizations (
+  id                SERIAL PRIMARY KEY,
+  device_code_hash  VARCHAR(64) NOT NULL UNIQUE,
+  user_code         VARCHAR(16) NOT NULL UNIQUE,
+  user_id           INTEGER DEFAULT NULL REFERENCES users (id) ON DELETE CASCADE,
+  state             VARCHAR(16) NOT NULL DEFAULT 'pending',
+  poll_interval     INTEGER NOT NULL,
+  last_polled_at    TIMESTAMP DEFAULT NULL,
+  expires_at        TIMESTAMP NOT NULL,
+  created_at        TIMESTAMP NOT NULL DEFAULT NOW()
+);
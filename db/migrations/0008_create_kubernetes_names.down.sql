@@ -0,0 +1 @@
+DROP TABLE IF EXISTS kubernetes_names;
@@ -0,0 +1,6 @@
+-- The names of the users in Kubernetes (their namespaces), each name belongs to the only user
+CREATE TABLE kubernetes_names (
+  name        VARCHAR(63) PRIMARY KEY,
+  user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
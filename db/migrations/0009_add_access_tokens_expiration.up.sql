@@ -0,0 +1,2 @@
+-- The tokens expire at expires_at, the ones without it are valid until they are revoked
+ALTER TABLE access_tokens ADD COLUMN expires_at TIMESTAMP DEFAULT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS device_authorizations_client_ip_idx;
+ALTER TABLE device_authorizations DROP COLUMN IF EXISTS client_ip;
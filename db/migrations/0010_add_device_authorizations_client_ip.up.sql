@@ -0,0 +1,3 @@
+-- The address the device code was requested from, the number of pending sign ins per address is limited
+ALTER TABLE device_authorizations ADD COLUMN client_ip VARCHAR(64) NOT NULL DEFAULT '';
+CREATE INDEX device_authorizations_client_ip_idx ON device_authorizations (client_ip);
@@ -0,0 +1,311 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"gopkg.in/reform.v1"
+)
+
+// lockID is a key of PostgreSQL advisory lock which protects from concurrent migrations
+// when several instances of the service start up simultaneously
+const lockID = 7402811
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration is a numbered schema change with its rollback
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// State describes a migration and the time it was applied at (nil if it wasn't)
+type State struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies embedded migrations and tracks them in schema_migrations table
+type Migrator struct {
+	db         *reform.DB
+	log        logrus.FieldLogger
+	migrations []Migration
+}
+
+// New creates Migrator with all the migrations embedded into the binary
+func New(db *reform.DB, log logrus.FieldLogger) (*Migrator, error) {
+	migrations, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, log: log, migrations: migrations}, nil
+}
+
+// load parses embedded files named as <version>_<name>.<up|down>.sql
+func load() ([]Migration, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		file := entry.Name()
+		parts := strings.SplitN(strings.TrimSuffix(file, ".sql"), "_", 2)
+		ext := path.Ext(strings.TrimSuffix(file, ".sql"))
+		if len(parts) != 2 || (ext != ".up" && ext != ".down") {
+			return nil, fmt.Errorf("migration %s: name must be <version>_<name>.<up|down>.sql", file)
+		}
+
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: wrong version: %v", file, err)
+		}
+
+		data, err := files.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: strings.TrimSuffix(parts[1], ext)}
+			byVersion[version] = m
+		}
+
+		if ext == ".up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s: both up and down files are required", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Status returns the states of all known migrations, the database isn't changed
+func (m *Migrator) Status() ([]State, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]State, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		state := State{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			state.AppliedAt = &appliedAt
+		}
+		states = append(states, state)
+	}
+
+	return states, nil
+}
+
+// Pending returns the migrations which haven't been applied yet, the database isn't changed
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Up applies all pending migrations
+func (m *Migrator) Up() error {
+	return m.locked(func() error {
+		_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    BIGINT PRIMARY KEY,
+			name       VARCHAR(256) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)`)
+		if err != nil {
+			return fmt.Errorf("couldn't create schema_migrations table: %v", err)
+		}
+
+		pending, err := m.Pending()
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range pending {
+			if err := m.up(migration); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down rolls back the last applied migration
+func (m *Migrator) Down() error {
+	return m.locked(func() error {
+		migration, err := m.last()
+		if err != nil {
+			return err
+		}
+
+		return m.down(migration)
+	})
+}
+
+// Redo rolls back and applies again the last applied migration
+func (m *Migrator) Redo() error {
+	return m.locked(func() error {
+		migration, err := m.last()
+		if err != nil {
+			return err
+		}
+
+		if err := m.down(migration); err != nil {
+			return err
+		}
+
+		return m.up(migration)
+	})
+}
+
+func (m *Migrator) up(migration Migration) error {
+	logger := m.log.WithField("migration", fmt.Sprintf("%04d_%s", migration.Version, migration.Name))
+	logger.Info("Applying migration...")
+
+	err := m.db.InTransaction(func(tx *reform.TX) error {
+		if _, err := tx.Exec(migration.Up); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(
+			"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name,
+		)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't apply migration %d_%s: %v", migration.Version, migration.Name, err)
+	}
+
+	logger.Info("Migration was applied")
+	return nil
+}
+
+func (m *Migrator) down(migration Migration) error {
+	logger := m.log.WithField("migration", fmt.Sprintf("%04d_%s", migration.Version, migration.Name))
+	logger.Info("Rolling back migration...")
+
+	err := m.db.InTransaction(func(tx *reform.TX) error {
+		if _, err := tx.Exec(migration.Down); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't roll back migration %d_%s: %v", migration.Version, migration.Name, err)
+	}
+
+	logger.Info("Migration was rolled back")
+	return nil
+}
+
+// last returns the last applied migration
+func (m *Migrator) last() (Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return Migration{}, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			return m.migrations[i], nil
+		}
+	}
+
+	return Migration{}, fmt.Errorf("there are no applied migrations")
+}
+
+// applied returns versions of the applied migrations with the time they were applied at,
+// none of them is applied if schema_migrations table doesn't exist yet (it's created by Up)
+func (m *Migrator) applied() (map[int64]time.Time, error) {
+	var exists bool
+	if err := m.db.QueryRow("SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("couldn't check schema_migrations table: %v", err)
+	}
+	if !exists {
+		return map[int64]time.Time{}, nil
+	}
+
+	rows, err := m.db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// locked runs f holding PostgreSQL advisory lock on a dedicated connection
+func (m *Migrator) locked(f func() error) error {
+	db, ok := m.db.DBInterface().(*sql.DB)
+	if !ok {
+		return f()
+	}
+
+	// Session-level advisory locks are bound to the connection,
+	// so the same connection has to be used to release the lock
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("couldn't acquire migrations lock: %v", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+
+	return f()
+}
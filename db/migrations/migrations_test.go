@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	_ "github.com/lib/pq" // postgresql driver
+	"gopkg.in/reform.v1"
+	"gopkg.in/reform.v1/dialects/postgresql"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := load()
+	if err != nil {
+		t.Fatalf("load() = %v", err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("migration %s has version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("migration %d_%s doesn't have both up and down parts", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestStatusReadOnly(t *testing.T) {
+	dsn := os.Getenv("UI_TEST_DB")
+	if dsn == "" {
+		t.Skip("UI_TEST_DB is not set")
+	}
+
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The only connection keeps the search path of the empty schema
+	conn.SetMaxOpenConns(1)
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	if _, err = conn.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Exec("DROP SCHEMA " + schema + " CASCADE")
+	if _, err = conn.Exec("SET search_path TO " + schema); err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := New(reform.NewDB(conn, postgresql.Dialect, nil), logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		t.Fatalf("Pending() = %v", err)
+	}
+	if len(pending) != len(migrator.migrations) {
+		t.Errorf("Pending() = %d migrations, want all %d", len(pending), len(migrator.migrations))
+	}
+	states, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status() = %v", err)
+	}
+	for _, state := range states {
+		if state.AppliedAt != nil {
+			t.Errorf("migration %d is applied in the empty schema", state.Version)
+		}
+	}
+
+	var exists bool
+	if err = conn.QueryRow("SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("schema_migrations table is created by Pending() or Status()")
+	}
+}
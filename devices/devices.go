@@ -0,0 +1,252 @@
+// Package devices implements the device authorization grant (RFC 8628):
+// the device (the CLI) gets a device code and a user code, the user approves the sign in
+// on the verification page with the user code, and the device polls for the result with the device code.
+package devices
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/models"
+)
+
+// GrantType is the grant type of the requests polling for the result
+const GrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// userCodeAlphabet has no vowels, so the codes don't make words, and no similar looking characters
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of characters of the user code without the dash
+const userCodeLength = 8
+
+// MaxPerClient is the maximum number of the sign ins started from the same address and not finished yet
+const MaxPerClient = 5
+
+// maxClientIPLength is the length of client_ip column
+const maxClientIPLength = 64
+
+// slowDownStep is added to the polling interval of the device which polls too often
+const slowDownStep = 5
+
+// ErrInvalid is returned for the user codes which don't exist, are expired or used already
+var ErrInvalid = errors.New("user code is invalid, expired or used already")
+
+// ErrTooMany is returned if MaxPerClient sign ins from the address haven't been finished yet
+var ErrTooMany = errors.New("too many sign ins from the address")
+
+// The errors of the polling, the texts are the error codes of RFC 8628
+var (
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	ErrSlowDown             = errors.New("slow_down")
+	ErrAccessDenied         = errors.New("access_denied")
+	ErrExpired              = errors.New("expired_token")
+	ErrInvalidGrant         = errors.New("invalid_grant")
+)
+
+// Start creates a new device authorization requested from the client address,
+// the device code is returned only once since only its hash is kept
+func Start(db *reform.DB, clientIP string, ttl, interval time.Duration) (string, *models.DeviceAuthorization, error) {
+	// Forget the devices which have never finished the sign in
+	if _, err := db.DeleteFrom(models.DeviceAuthorizationTable, "WHERE expires_at < $1", time.Now().UTC()); err != nil {
+		return "", nil, err
+	}
+
+	if len(clientIP) > maxClientIPLength {
+		clientIP = clientIP[:maxClientIPLength]
+	}
+	var started int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM "+db.QualifiedView(models.DeviceAuthorizationTable)+" WHERE client_ip = $1", clientIP,
+	).Scan(&started)
+	if err != nil {
+		return "", nil, err
+	}
+	if started >= MaxPerClient {
+		return "", nil, ErrTooMany
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	deviceCode := base64.RawURLEncoding.EncodeToString(b)
+
+	userCode, err := generateUserCode()
+	if err != nil {
+		return "", nil, err
+	}
+
+	authorization := &models.DeviceAuthorization{
+		DeviceCodeHash: hash(deviceCode),
+		UserCode:       userCode,
+		State:          models.DevicePending,
+		PollInterval:   int(interval / time.Second),
+		ExpiresAt:      time.Now().UTC().Truncate(time.Second).Add(ttl),
+		ClientIP:       clientIP,
+	}
+	if err = db.Insert(authorization); err != nil {
+		return "", nil, err
+	}
+
+	return deviceCode, authorization, nil
+}
+
+// Find returns the pending device authorization specified by the user code or ErrInvalid
+func Find(db *reform.DB, userCode string) (*models.DeviceAuthorization, error) {
+	st, err := db.FindOneFrom(models.DeviceAuthorizationTable, "user_code", NormalizeUserCode(userCode))
+	if err == reform.ErrNoRows {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	authorization := st.(*models.DeviceAuthorization)
+	if authorization.State != models.DevicePending || !time.Now().UTC().Before(authorization.ExpiresAt) {
+		return nil, ErrInvalid
+	}
+
+	return authorization, nil
+}
+
+// Approve allows the device to sign in as the user
+func Approve(db *reform.DB, userCode string, userID int64) error {
+	return decide(db, userCode, userID, models.DeviceApproved)
+}
+
+// Deny rejects the sign in of the device
+func Deny(db *reform.DB, userCode string, userID int64) error {
+	return decide(db, userCode, userID, models.DeviceDenied)
+}
+
+// decide sets the state of the pending device authorization
+func decide(db *reform.DB, userCode string, userID int64, state string) error {
+	return db.InTransaction(func(tx *reform.TX) error {
+		st, err := tx.SelectOneFrom(
+			models.DeviceAuthorizationTable, "WHERE user_code = $1 FOR UPDATE", NormalizeUserCode(userCode),
+		)
+		if err == reform.ErrNoRows {
+			return ErrInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		authorization := st.(*models.DeviceAuthorization)
+		if authorization.State != models.DevicePending || !time.Now().UTC().Before(authorization.ExpiresAt) {
+			return ErrInvalid
+		}
+
+		authorization.State = state
+		authorization.UserID = &userID
+
+		return tx.UpdateColumns(authorization, "state", "user_id")
+	})
+}
+
+// Poll returns the approved device authorization, it's deleted then, so the device code can't be used twice.
+// The approved function is called in the same transaction, e.g. to issue the device's token:
+// if it fails, the authorization is kept and the device may poll again.
+// One of the polling errors is returned if the sign in isn't approved.
+func Poll(
+	db *reform.DB, deviceCode string, approved func(tx *reform.TX, authorization *models.DeviceAuthorization) error,
+) (*models.DeviceAuthorization, error) {
+	var authorization *models.DeviceAuthorization
+	var result error
+
+	err := db.InTransaction(func(tx *reform.TX) error {
+		st, err := tx.SelectOneFrom(
+			models.DeviceAuthorizationTable, "WHERE device_code_hash = $1 FOR UPDATE", hash(deviceCode),
+		)
+		if err == reform.ErrNoRows {
+			result = ErrInvalidGrant
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		authorization = st.(*models.DeviceAuthorization)
+
+		now := time.Now().UTC()
+		switch {
+		case !now.Before(authorization.ExpiresAt):
+			result = ErrExpired
+			return tx.Delete(authorization)
+		case authorization.State == models.DeviceDenied:
+			result = ErrAccessDenied
+			return tx.Delete(authorization)
+		case authorization.State == models.DeviceApproved:
+			if err = approved(tx, authorization); err != nil {
+				return err
+			}
+			return tx.Delete(authorization)
+		}
+
+		interval := time.Duration(authorization.PollInterval) * time.Second
+		if authorization.LastPolledAt != nil && now.Sub(*authorization.LastPolledAt) < interval {
+			result = ErrSlowDown
+			authorization.PollInterval += slowDownStep
+		} else {
+			result = ErrAuthorizationPending
+		}
+		authorization.LastPolledAt = &now
+
+		return tx.UpdateColumns(authorization, "poll_interval", "last_polled_at")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		return nil, result
+	}
+
+	return authorization, nil
+}
+
+// NormalizeUserCode makes the user codes case-insensitive and formats them as XXXX-XXXX,
+// so the users may type them without the dash
+func NormalizeUserCode(code string) string {
+	code = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+
+	if len(code) != userCodeLength {
+		return code
+	}
+
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
+
+// generateUserCode returns a random user code formatted as XXXX-XXXX,
+// all the characters of the alphabet are equally likely
+func generateUserCode() (string, error) {
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+
+	b := make([]byte, userCodeLength)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = userCodeAlphabet[n.Int64()]
+	}
+
+	return NormalizeUserCode(string(b)), nil
+}
+
+// hash returns the hash of the device code kept in DB
+func hash(deviceCode string) string {
+	sum := sha256.Sum256([]byte(deviceCode))
+	return hex.EncodeToString(sum[:])
+}
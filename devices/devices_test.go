@@ -0,0 +1,193 @@
+package devices
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/db/dbtest"
+	"github.com/k8s-community/ui/models"
+)
+
+func TestGenerateUserCode(t *testing.T) {
+	seen := make(map[rune]int)
+	for i := 0; i < 1000; i++ {
+		code, err := generateUserCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != userCodeLength+1 || code[userCodeLength/2] != '-' || NormalizeUserCode(code) != code {
+			t.Fatalf("generateUserCode() = %s, want XXXX-XXXX", code)
+		}
+		for _, r := range strings.Replace(code, "-", "", 1) {
+			if !strings.ContainsRune(userCodeAlphabet, r) {
+				t.Fatalf("generateUserCode() = %s, %c isn't in the alphabet", code, r)
+			}
+			seen[r]++
+		}
+	}
+
+	if len(seen) != len(userCodeAlphabet) {
+		t.Errorf("%d characters of %d are used", len(seen), len(userCodeAlphabet))
+	}
+}
+
+func TestNormalizeUserCode(t *testing.T) {
+	for code, want := range map[string]string{
+		"BCDF-GHJK":  "BCDF-GHJK",
+		"bcdfghjk":   "BCDF-GHJK",
+		" bcdf ghjk": "BCDF-GHJK",
+		"BCD":        "BCD",
+	} {
+		if got := NormalizeUserCode(code); got != want {
+			t.Errorf("NormalizeUserCode(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+// start starts the sign in of the device from the unique address
+func start(t *testing.T, db *reform.DB) (string, *models.DeviceAuthorization) {
+	deviceCode, authorization, err := Start(db, dbtest.Login("127.0.0.1"), time.Minute, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+
+	return deviceCode, authorization
+}
+
+// user creates the user approving the sign ins
+func user(t *testing.T, db *reform.DB) *models.User {
+	user := &models.User{Name: dbtest.Login("alice"), Source: models.SourceGitHub}
+	if err := db.Insert(user); err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
+
+// notApproved is the callback of Poll which shouldn't be called
+func notApproved(t *testing.T) func(tx *reform.TX, authorization *models.DeviceAuthorization) error {
+	return func(tx *reform.TX, authorization *models.DeviceAuthorization) error {
+		t.Errorf("device authorization %d is approved", authorization.ID)
+		return nil
+	}
+}
+
+func TestPollPending(t *testing.T) {
+	db := dbtest.DB(t)
+	deviceCode, authorization := start(t, db)
+
+	if _, err := Poll(db, deviceCode, notApproved(t)); err != ErrAuthorizationPending {
+		t.Errorf("Poll() = %v, want %v", err, ErrAuthorizationPending)
+	}
+	if _, err := Poll(db, deviceCode, notApproved(t)); err != ErrSlowDown {
+		t.Errorf("Poll() right after the previous one = %v, want %v", err, ErrSlowDown)
+	}
+
+	found, err := Find(db, authorization.UserCode)
+	if err != nil {
+		t.Fatalf("Find() = %v", err)
+	}
+	if found.PollInterval != authorization.PollInterval+slowDownStep {
+		t.Errorf("poll interval = %d, want %d", found.PollInterval, authorization.PollInterval+slowDownStep)
+	}
+
+	if _, err = Poll(db, "unknown", notApproved(t)); err != ErrInvalidGrant {
+		t.Errorf("Poll() of unknown device code = %v, want %v", err, ErrInvalidGrant)
+	}
+}
+
+func TestPollExpired(t *testing.T) {
+	db := dbtest.DB(t)
+	deviceCode, authorization := start(t, db)
+
+	authorization.ExpiresAt = time.Now().UTC().Truncate(time.Second).Add(-time.Second)
+	if err := db.UpdateColumns(authorization, "expires_at"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Approve(db, authorization.UserCode, user(t, db).ID); err != ErrInvalid {
+		t.Errorf("Approve() of expired code = %v, want %v", err, ErrInvalid)
+	}
+
+	if _, err := Poll(db, deviceCode, notApproved(t)); err != ErrExpired {
+		t.Errorf("Poll() = %v, want %v", err, ErrExpired)
+	}
+	if _, err := Poll(db, deviceCode, notApproved(t)); err != ErrInvalidGrant {
+		t.Errorf("Poll() after expiration = %v, want %v", err, ErrInvalidGrant)
+	}
+}
+
+func TestPollDenied(t *testing.T) {
+	db := dbtest.DB(t)
+	deviceCode, authorization := start(t, db)
+
+	if err := Deny(db, authorization.UserCode, user(t, db).ID); err != nil {
+		t.Fatalf("Deny() = %v", err)
+	}
+
+	if _, err := Poll(db, deviceCode, notApproved(t)); err != ErrAccessDenied {
+		t.Errorf("Poll() = %v, want %v", err, ErrAccessDenied)
+	}
+	if _, err := Poll(db, deviceCode, notApproved(t)); err != ErrInvalidGrant {
+		t.Errorf("Poll() after denial = %v, want %v", err, ErrInvalidGrant)
+	}
+}
+
+func TestPollApproved(t *testing.T) {
+	db := dbtest.DB(t)
+	deviceCode, authorization := start(t, db)
+	alice := user(t, db)
+
+	if err := Approve(db, authorization.UserCode, alice.ID); err != nil {
+		t.Fatalf("Approve() = %v", err)
+	}
+	if err := Deny(db, authorization.UserCode, alice.ID); err != ErrInvalid {
+		t.Errorf("Deny() of approved code = %v, want %v", err, ErrInvalid)
+	}
+
+	// The authorization is kept if the token isn't issued
+	failure := errors.New("token isn't issued")
+	_, err := Poll(db, deviceCode, func(tx *reform.TX, authorization *models.DeviceAuthorization) error {
+		return failure
+	})
+	if err != failure {
+		t.Errorf("Poll() with failed callback = %v, want %v", err, failure)
+	}
+
+	issued := 0
+	approved, err := Poll(db, deviceCode, func(tx *reform.TX, authorization *models.DeviceAuthorization) error {
+		issued++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Poll() = %v", err)
+	}
+	if approved.UserID == nil || *approved.UserID != alice.ID || issued != 1 {
+		t.Errorf("Poll() = %v with %d tokens issued, want authorization of user %d with one token", approved, issued, alice.ID)
+	}
+
+	// The device code can't be used twice
+	if _, err = Poll(db, deviceCode, notApproved(t)); err != ErrInvalidGrant {
+		t.Errorf("Poll() after sign in = %v, want %v", err, ErrInvalidGrant)
+	}
+}
+
+func TestStartLimit(t *testing.T) {
+	db := dbtest.DB(t)
+	clientIP := dbtest.Login("127.0.0.1")
+
+	for i := 0; i < MaxPerClient; i++ {
+		if _, _, err := Start(db, clientIP, time.Minute, 5*time.Second); err != nil {
+			t.Fatalf("Start() %d = %v", i, err)
+		}
+	}
+	if _, _, err := Start(db, clientIP, time.Minute, 5*time.Second); err != ErrTooMany {
+		t.Errorf("Start() over the limit = %v, want %v", err, ErrTooMany)
+	}
+
+	// The other clients aren't affected
+	start(t, db)
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/icza/session"
+	"github.com/takama/router"
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/access"
+	"github.com/k8s-community/ui/builds"
+	"github.com/k8s-community/ui/models"
+	"github.com/k8s-community/ui/participants"
+	"github.com/k8s-community/ui/provisioning"
+	"github.com/k8s-community/ui/views"
+)
+
+// adminPath is the path of the dashboard with the participants
+const adminPath = "/admin"
+
+// participantsPerPage is the number of the users on a page of the dashboard
+const participantsPerPage = 25
+
+// adminSorts are the orders of the dashboard offered to the instructors
+var adminSorts = []sortOption{
+	{Value: participants.SortLogin, Title: "Login"},
+	{Value: participants.SortSource, Title: "Source"},
+	{Value: participants.SortCreated, Title: "Newest"},
+	{Value: participants.SortUpdated, Title: "Recently updated"},
+}
+
+// sortOption is the order of the list offered on the page
+type sortOption struct {
+	Value string
+	Title string
+}
+
+// participantRow is the user shown on the dashboard
+type participantRow struct {
+	ID        int64
+	Login     string
+	Source    string
+	Status    *ProvisioningStatus
+	LastError string // the error of the last provisioning attempt
+	Sessions  int
+	LastBuild *builds.Build // nil if the user has no builds or they couldn't be got
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// adminPage is the data of the dashboard
+type adminPage struct {
+	Login   string
+	IsAdmin bool   // the admins can also clear the credentials and sign the users out
+	CSRF    string // the forms are accepted only with this value
+
+	BuildHistory bool // the last builds are shown only if the build history is enabled
+
+	Search       string
+	Sort         string
+	Sorts        []sortOption
+	Participants []*participantRow
+	Total        int
+	Page         int
+	Pages        int
+	PrevLink     string // link to the previous page, empty on the first page
+	NextLink     string // link to the next page, empty on the last page
+	Return       string // the forms return to the current page
+}
+
+// Admin is a handler set of the dashboard where the instructors and the admins see the participants
+// and fix their environments
+type Admin struct {
+	db           *reform.DB
+	log          logrus.FieldLogger
+	pages        *views.Registry
+	errorPages   *ErrorPages
+	roles        access.Roles
+	provisioning *provisioning.Queue
+	builds       *builds.Client
+	history      bool
+}
+
+// NewAdmin creates Admin handler set, the dashboard is available to the users with the roles
+// of the instructor and the admin. The last builds of the users are got with the client if history is enabled.
+func NewAdmin(
+	db *reform.DB, log logrus.FieldLogger, pages *views.Registry, errorPages *ErrorPages,
+	roles access.Roles, queue *provisioning.Queue, client *builds.Client, history bool,
+) *Admin {
+	return &Admin{
+		db:           db,
+		log:          log,
+		pages:        pages,
+		errorPages:   errorPages,
+		roles:        roles,
+		provisioning: queue,
+		builds:       client,
+		history:      history,
+	}
+}
+
+// Page is a handler of the dashboard: GET /admin?q=<search>&sort=<order>&page=<page>
+func (h *Admin) Page(c *router.Control) {
+	sessionData, role := h.staff(c)
+	if sessionData == nil {
+		return
+	}
+
+	page, err := strconv.Atoi(c.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	data := &adminPage{
+		Login:   sessionData.CAttr("Login").(string),
+		IsAdmin: role == access.RoleAdmin,
+		CSRF:    csrfToken(sessionData),
+
+		BuildHistory: h.history,
+
+		Search: strings.TrimSpace(c.Get("q")),
+		Sort:   c.Get("sort"),
+		Sorts:  adminSorts,
+		Page:   page,
+	}
+	if data.Sort == "" {
+		data.Sort = participants.SortLogin
+	}
+
+	list, total, err := participants.List(h.db, participants.ListOptions{
+		Search:  data.Search,
+		Sort:    data.Sort,
+		Page:    page,
+		PerPage: participantsPerPage,
+	})
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't list participants: %v", err))
+		return
+	}
+
+	data.Total = total
+	data.Pages = (total + participantsPerPage - 1) / participantsPerPage
+	data.Return = adminPageLink(data.Search, data.Sort, page)
+	if page > 1 {
+		data.PrevLink = adminPageLink(data.Search, data.Sort, page-1)
+	}
+	if page < data.Pages {
+		data.NextLink = adminPageLink(data.Search, data.Sort, page+1)
+	}
+
+	data.Participants = make([]*participantRow, 0, len(list))
+	for _, p := range list {
+		row := &participantRow{
+			ID:        p.User.ID,
+			Login:     p.User.Name,
+			Source:    p.User.Source,
+			Status:    newProvisioningStatus(p.User, p.Job),
+			Sessions:  p.Sessions,
+			CreatedAt: p.User.CreatedAt,
+			UpdatedAt: p.User.UpdatedAt,
+		}
+		if p.Job != nil && p.Job.LastError != nil {
+			row.LastError = *p.Job.LastError
+		}
+		data.Participants = append(data.Participants, row)
+	}
+	if h.history {
+		h.lastBuilds(data.Participants)
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Cache-Control", "no-store")
+	if err = h.pages.Render(c.Writer, language(c, h.pages), "admin", data); err != nil {
+		h.log.WithField("user", data.Login).Errorf("Couldn't render admin page: %+v", err)
+	}
+}
+
+// Act is a handler of the actions on the user: POST /admin/users/:id
+// The action is one of "resync" (run the provisioning again), "clear" (forget the Kubernetes credentials)
+// and "signout" (delete all the sessions of the user), the latter two are available to the admins only.
+func (h *Admin) Act(c *router.Control) {
+	sessionData, role := h.staff(c)
+	if sessionData == nil {
+		return
+	}
+
+	csrf := c.Request.PostFormValue("csrf")
+	if !validCSRF(csrf, sessionData) {
+		h.errorPages.Render(c, NewError(
+			http.StatusForbidden, "The form has expired, please try again", fmt.Errorf("wrong CSRF token %q", csrf),
+		))
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Get(":id"), 10, 64)
+	if err != nil {
+		h.errorPages.Render(c, NewError(http.StatusNotFound, "", err))
+		return
+	}
+
+	user, err := participants.Find(h.db, id)
+	if err == participants.ErrNotFound {
+		h.errorPages.Render(c, NewError(http.StatusNotFound, "The user is not found", nil))
+		return
+	}
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't get user %d from DB: %v", id, err))
+		return
+	}
+
+	action := c.Request.PostFormValue("action")
+	if (action == "clear" || action == "signout") && role != access.RoleAdmin {
+		h.errorPages.Render(c, NewError(http.StatusForbidden, "Only the admins can do it", nil))
+		return
+	}
+
+	logger := h.log.WithFields(logrus.Fields{"user": sessionData.CAttr("Login"), "participant": user.Name})
+	switch action {
+	case "resync":
+		if err = h.provisioning.Enqueue(user.Source, user.Name); err != nil {
+			h.errorPages.Render(c, fmt.Errorf("couldn't enqueue provisioning job of user %s: %v", user.Name, err))
+			return
+		}
+		logger.Infof("Provisioning job was enqueued")
+	case "clear":
+		if err = participants.ClearCredentials(h.db, user); err != nil {
+			h.errorPages.Render(c, fmt.Errorf("couldn't clear credentials of user %s: %v", user.Name, err))
+			return
+		}
+		logger.Infof("Credentials were cleared")
+	case "signout":
+		sessions, err := participants.SignOut(h.db, user)
+		if err != nil {
+			h.errorPages.Render(c, fmt.Errorf("couldn't delete sessions of user %s: %v", user.Name, err))
+			return
+		}
+		logger.Infof("%d sessions were deleted", sessions)
+	default:
+		h.errorPages.Render(c, NewError(http.StatusBadRequest, "Unknown action", fmt.Errorf("action %q", action)))
+		return
+	}
+
+	next := c.Request.PostFormValue("return")
+	if !localPath(next) || !strings.HasPrefix(next, adminPath) {
+		next = adminPath
+	}
+	http.Redirect(c.Writer, c.Request, next, http.StatusSeeOther)
+}
+
+// staff returns the session and the role of the instructor or the admin.
+// The guests are redirected to the home page, the participants get 403, nil is returned then.
+func (h *Admin) staff(c *router.Control) (session.Session, string) {
+	sessionData := session.Get(c.Request)
+	if sessionData == nil {
+		http.Redirect(c.Writer, c.Request, "/", http.StatusFound)
+		return nil, ""
+	}
+
+	role := h.roles.Role(sessionData.CAttr("Source").(string), sessionData.CAttr("Login").(string))
+	if role == access.RoleParticipant {
+		h.errorPages.Render(c, NewError(http.StatusForbidden, "The page is available to the workshop instructors only", nil))
+		return nil, ""
+	}
+
+	return sessionData, role
+}
+
+// lastBuilds gets the last builds of the users concurrently, github-integration builds
+// the repositories of GitHub users only, so the others are skipped
+func (h *Admin) lastBuilds(rows []*participantRow) {
+	var wg sync.WaitGroup
+	for _, row := range rows {
+		if row.Source != models.SourceGitHub {
+			continue
+		}
+
+		wg.Add(1)
+		go func(row *participantRow) {
+			defer wg.Done()
+
+			list, err := h.builds.List(builds.ListOptions{Username: row.Login, Page: 1, PerPage: 1})
+			if err != nil {
+				h.log.WithField("participant", row.Login).Errorf("Couldn't get last build: %+v", err)
+				return
+			}
+			if len(list.Builds) > 0 {
+				row.LastBuild = list.Builds[0]
+			}
+		}(row)
+	}
+	wg.Wait()
+}
+
+// adminPageLink returns the link to the page of the dashboard keeping the search and the order
+func adminPageLink(search, sort string, page int) string {
+	query := url.Values{}
+	if search != "" {
+		query.Set("q", search)
+	}
+	query.Set("sort", sort)
+	query.Set("page", strconv.Itoa(page))
+
+	return adminPath + "?" + query.Encode()
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/takama/router"
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/access"
+	"github.com/k8s-community/ui/builds"
+	"github.com/k8s-community/ui/kubeconfig"
+	"github.com/k8s-community/ui/models"
+	"github.com/k8s-community/ui/tokens"
+)
+
+// apiPrefix is the path prefix of JSON API, the errors of these requests are always returned as JSON
+const apiPrefix = "/api/"
+
+// apiMaxPerPage is the maximum number of the builds returned by the API at once
+const apiMaxPerPage = 100
+
+// Account is the signed in user with the provisioning status of the environment
+type Account struct {
+	Login  string `json:"login"`
+	Source string `json:"source"`
+	ProvisioningStatus
+}
+
+// Credentials are the user's Kubernetes credentials
+type Credentials struct {
+	Token      string `json:"token"`
+	CA         string `json:"ca"`         // PEM-encoded ca.crt
+	Kubeconfig string `json:"kubeconfig"` // kubeconfig file (YAML) with the credentials
+}
+
+// API is a handler set of JSON API for the scripts, it's described by static/api/openapi.yaml.
+// The requests are authorized with the session cookie or with the personal access token.
+type API struct {
+	db         *reform.DB
+	log        logrus.FieldLogger
+	errorPages *ErrorPages
+	builds     *builds.Client
+	rules      access.Builds
+	cluster    Cluster
+}
+
+// NewAPI creates API handler set
+func NewAPI(
+	db *reform.DB, log logrus.FieldLogger, errorPages *ErrorPages,
+	client *builds.Client, rules access.Builds, cluster Cluster,
+) *API {
+	return &API{db: db, log: log, errorPages: errorPages, builds: client, rules: rules, cluster: cluster}
+}
+
+// Me is a handler to get the signed in user: GET /api/v1/me
+func (h *API) Me(c *router.Control) {
+	source, login, ok := h.authorize(c, "")
+	if !ok {
+		return
+	}
+
+	status, err := provisioningStatus(h.db, source, login)
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't get provisioning status of user %s: %v", login, err))
+		return
+	}
+
+	c.Writer.Header().Set("Cache-Control", "no-store")
+	c.Code(http.StatusOK).Body(Account{Login: login, Source: source, ProvisioningStatus: *status})
+}
+
+// Credentials is a handler to get Kubernetes credentials of the signed in user: GET /api/v1/me/credentials
+func (h *API) Credentials(c *router.Control) {
+	source, login, ok := h.authorize(c, tokens.ScopeCredentials)
+	if !ok {
+		return
+	}
+
+	user, err := findCredentials(h.db, source, login)
+	if err != nil {
+		h.errorPages.Render(c, err)
+		return
+	}
+
+	config, err := kubeconfig.New(h.cluster.Name, h.cluster.APIServer, user.KubernetesName(), *user.Token, *user.Cert).Marshal()
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't marshal kubeconfig of user %s: %v", login, err))
+		return
+	}
+
+	c.Writer.Header().Set("Cache-Control", "no-store")
+	c.Code(http.StatusOK).Body(Credentials{Token: *user.Token, CA: *user.Cert, Kubeconfig: string(config)})
+}
+
+// Builds is a handler to get the builds of the signed in user, the newest first:
+// GET /api/v1/builds?repository=<repository>&page=<page>&perPage=<perPage>
+// The list is empty for the users of the sources other than GitHub.
+func (h *API) Builds(c *router.Control) {
+	source, login, ok := h.authorize(c, tokens.ScopeBuilds)
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(c.Get("perPage"))
+	if err != nil || perPage < 1 {
+		perPage = buildsPerPage
+	}
+	if perPage > apiMaxPerPage {
+		perPage = apiMaxPerPage
+	}
+
+	list := &builds.List{Builds: []*builds.Build{}}
+	if source == models.SourceGitHub {
+		list, err = h.builds.List(builds.ListOptions{
+			Username:   login,
+			Repository: strings.TrimSpace(c.Get("repository")),
+			Page:       page,
+			PerPage:    perPage,
+		})
+		if err != nil {
+			h.errorPages.Render(c, NewError(http.StatusBadGateway, "Couldn't get the builds, please try again later", err))
+			return
+		}
+	}
+
+	for _, build := range list.Builds {
+		build.State = build.Status()
+	}
+
+	c.Code(http.StatusOK).Body(list)
+}
+
+// Build is a handler to get the build with its log starting from the offset (in bytes):
+// GET /api/v1/builds/:uuid?offset=<offset>
+// The build is returned only to the users allowed by the rules, the others get 404.
+func (h *API) Build(c *router.Control) {
+	source, login, ok := h.identify(c, tokens.ScopeBuilds)
+	if !ok {
+		return
+	}
+
+	uuid := c.Get(":uuid")
+	offset, err := strconv.Atoi(c.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	build, err := h.builds.Results(uuid, offset)
+	if err == builds.ErrNotFound {
+		h.errorPages.Render(c, NewError(http.StatusNotFound, "The build is not found", nil))
+		return
+	}
+	if err != nil {
+		h.errorPages.Render(c, NewError(http.StatusBadGateway, "Couldn't get the build, please try again later", err))
+		return
+	}
+
+	if !h.rules.CanView(source, login, build.Username, build.Repository) {
+		// Don't tell the build exists
+		h.errorPages.Render(c, NewError(http.StatusNotFound, "The build is not found", nil))
+		return
+	}
+
+	build.State = build.Status()
+	c.Code(http.StatusOK).Body(build)
+}
+
+// authorize returns the source and the login of the user,
+// 401 is written and false is returned if the user isn't signed in and doesn't present the token
+func (h *API) authorize(c *router.Control, scope string) (source, login string, ok bool) {
+	source, login, ok = h.identify(c, scope)
+	if ok && login == "" {
+		h.errorPages.Render(c, NewError(http.StatusUnauthorized, "", nil))
+		return "", "", false
+	}
+
+	return source, login, ok
+}
+
+// identify returns the source and the login of the owner of the access token (Authorization: Bearer <token>)
+// or of the signed in user, they are empty for the guests. The token should have the scope if it's not empty.
+// The error is written and false is returned if the token is invalid or doesn't have the scope.
+func (h *API) identify(c *router.Control, scope string) (source, login string, ok bool) {
+	authorization := c.Request.Header.Get("Authorization")
+	if authorization == "" {
+		source, login = sessionUser(c.Request)
+		return source, login, true
+	}
+
+	// The other schemes are invalid as well
+	var token string
+	if strings.HasPrefix(authorization, "Bearer ") {
+		token = strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	}
+
+	accessToken, user, err := tokens.Authenticate(h.db, token)
+	if err == tokens.ErrInvalid {
+		c.Writer.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		h.errorPages.Render(c, NewError(http.StatusUnauthorized, "The access token is invalid, expired or revoked", nil))
+		return "", "", false
+	}
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't check access token: %v", err))
+		return "", "", false
+	}
+
+	if scope != "" && !accessToken.HasScope(scope) {
+		c.Writer.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+		h.errorPages.Render(c, NewError(
+			http.StatusForbidden, fmt.Sprintf("The access token doesn't have %s scope", scope), nil,
+		))
+		return "", "", false
+	}
+
+	return user.Source, user.Name, true
+}
+
+// APIDescription is a handler of OpenAPI description of the API kept in the file
+func APIDescription(file string) router.Handle {
+	return func(c *router.Control) {
+		c.Writer.Header().Set("Content-Type", "application/x-yaml")
+		http.ServeFile(c.Writer, c.Request, file)
+	}
+}
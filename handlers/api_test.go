@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/takama/router"
+
+	"github.com/k8s-community/ui/access"
+	"github.com/k8s-community/ui/db/dbtest"
+	"github.com/k8s-community/ui/models"
+	"github.com/k8s-community/ui/tokens"
+	"github.com/k8s-community/ui/views"
+)
+
+func TestAPITokenScope(t *testing.T) {
+	db := dbtest.DB(t)
+	pages, err := views.New("../templates", logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	api := NewAPI(db, log, NewErrorPages(log, pages), nil, access.Builds{}, Cluster{})
+
+	r := router.New()
+	r.GET("/api/v1/me", api.Me)
+	r.GET("/api/v1/me/credentials", api.Credentials)
+
+	user := &models.User{Name: dbtest.Login("alice"), Source: models.SourceGitHub}
+	if err = db.Insert(user); err != nil {
+		t.Fatal(err)
+	}
+	buildsToken, _, err := tokens.Create(db.Querier, user.ID, "builds", []string{tokens.ScopeBuilds}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialsToken, _, err := tokens.Create(db.Querier, user.ID, "credentials", []string{tokens.ScopeCredentials}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+		header string // the part of WWW-Authenticate header
+	}{
+		{name: "any token", path: "/api/v1/me", token: buildsToken, status: http.StatusOK},
+		{name: "wrong prefix", path: "/api/v1/me", token: "ghp_" + buildsToken, status: http.StatusUnauthorized, header: "invalid_token"},
+		{name: "no scope", path: "/api/v1/me/credentials", token: buildsToken, status: http.StatusForbidden, header: "insufficient_scope"},
+		// The credentials of the user aren't prepared yet
+		{name: "scope", path: "/api/v1/me/credentials", token: credentialsToken, status: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.path, nil)
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.name, w.Code, test.status)
+		}
+		if header := w.Header().Get("WWW-Authenticate"); !strings.Contains(header, test.header) {
+			t.Errorf("%s: WWW-Authenticate = %q, want %s", test.name, header, test.header)
+		}
+	}
+}
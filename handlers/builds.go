@@ -1,25 +1,32 @@
 package handlers
 
 import (
+	"bytes"
+	"fmt"
 	"html/template"
-	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/icza/session"
-	ghint "github.com/k8s-community/github-integration/client"
 	"github.com/takama/router"
+
+	"github.com/k8s-community/ui/access"
+	"github.com/k8s-community/ui/buildlog"
+	"github.com/k8s-community/ui/builds"
+	"github.com/k8s-community/ui/models"
+	"github.com/k8s-community/ui/views"
 )
 
-func BuildHistory(client *ghint.Client, lang string) router.Handle {
-	t, err := template.ParseFiles(
-		"templates/"+lang+"/layout.html",
-		"templates/"+lang+"/build-results.html",
-	)
-	if err != nil {
-		log.Fatalf("Couldn't parse template files: %+v", err)
-	}
+// buildsPerPage is the number of the builds on a page of the build history
+const buildsPerPage = 20
 
+// BuildList is a handler of the page with the builds of the signed in user, the builds are filtered
+// by ?repository= and paginated by ?page=
+func BuildList(client *builds.Client, logger logrus.FieldLogger, pages *views.Registry) router.Handle {
 	return func(c *router.Control) {
 		sessionData := session.Get(c.Request)
 		if sessionData == nil {
@@ -27,14 +34,274 @@ func BuildHistory(client *ghint.Client, lang string) router.Handle {
 			return
 		}
 
+		login := sessionData.CAttr("Login").(string)
+		source := sessionData.CAttr("Source").(string)
+
+		page, err := strconv.Atoi(c.Get("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		data := struct {
+			Login      string
+			Repository string          // the builds of this repository are shown, all of them if it's empty
+			Supported  bool            // github-integration builds only the repositories of github.com users
+			Builds     []*builds.Build // the builds on the page
+			Error      bool            // couldn't get the builds
+			Page       int
+			Pages      int
+			PrevLink   string // link to the previous page, empty on the first page
+			NextLink   string // link to the next page, empty on the last page
+		}{
+			Login:      login,
+			Repository: strings.TrimSpace(c.Get("repository")),
+			Supported:  source == models.SourceGitHub,
+			Page:       page,
+		}
+
+		if data.Supported {
+			list, err := client.List(builds.ListOptions{
+				Username:   login,
+				Repository: data.Repository,
+				Page:       page,
+				PerPage:    buildsPerPage,
+			})
+			if err != nil {
+				logger.WithField("user", login).Errorf("Couldn't get build history: %+v", err)
+				data.Error = true
+			} else {
+				data.Builds = list.Builds
+				data.Pages = (list.Total + buildsPerPage - 1) / buildsPerPage
+			}
+		}
+
+		if page > 1 {
+			data.PrevLink = buildsPageLink(data.Repository, page-1)
+		}
+		if page < data.Pages {
+			data.NextLink = buildsPageLink(data.Repository, page+1)
+		}
+
+		if err := pages.Render(c.Writer, language(c, pages), "builds", data); err != nil {
+			logger.WithField("user", login).Errorf("Couldn't render build history page: %+v", err)
+		}
+	}
+}
+
+// buildsPageLink returns the link to the page of the build history keeping the filter
+func buildsPageLink(repository string, page int) string {
+	query := url.Values{}
+	if repository != "" {
+		query.Set("repository", repository)
+	}
+	query.Set("page", strconv.Itoa(page))
+
+	return "/builds?" + query.Encode()
+}
+
+// BuildHistory is a handler of the page with the log of the build,
+// the log of the running build is updated by BuildLogStream.
+// The build is shown only to the users allowed by the rules, the others get 404.
+// The page links to the build history if it's enabled.
+func BuildHistory(
+	client *builds.Client, rules access.Builds, history bool,
+	logger logrus.FieldLogger, pages *views.Registry, errorPages *ErrorPages,
+) router.Handle {
+	return func(c *router.Control) {
 		uuid := c.Get(":uuid")
-		build, err := client.Build.ShowResults(uuid)
+		build, err := client.Results(uuid, 0)
+		if err == builds.ErrNotFound {
+			errorPages.Render(c, NewError(http.StatusNotFound, "The build is not found", nil))
+			return
+		}
 		if err != nil {
-			// todo
+			errorPages.Render(c, NewError(http.StatusBadGateway, "Couldn't get the build, please try again later", err))
+			return
+		}
+
+		source, login := sessionUser(c.Request)
+		if !rules.CanView(source, login, build.Username, build.Repository) {
+			// Don't tell the build exists
+			errorPages.Render(c, NewError(http.StatusNotFound, "The build is not found", nil))
+			return
 		}
 
-		build.Log = strings.Replace(build.Log, "\n", "<br>", -1)
+		lines := buildlog.Parse(build.Log)
+		data := struct {
+			Build    *builds.Results
+			Sections []*buildlog.Section // the log is escaped, so the output of the build can't inject HTML
+			LastLine int                 // the stream of the running build starts from this line
 
-		t.ExecuteTemplate(c.Writer, "layout", template.HTML(build.Log))
+			BuildsLink string // link to the build history, empty if it's disabled
+		}{
+			Build:    build,
+			Sections: buildlog.Sections(lines),
+		}
+		if history {
+			data.BuildsLink = "/builds"
+		}
+		if len(lines) > 0 {
+			data.LastLine = lines[len(lines)-1].Number
+		}
+
+		if err = pages.Render(c.Writer, language(c, pages), "build-results", data); err != nil {
+			logger.WithField("build", uuid).Errorf("Couldn't render build page: %+v", err)
+		}
 	}
 }
+
+// logLine is the line of the build log sent to the page
+type logLine struct {
+	Number  int    `json:"number"`
+	Section string `json:"section"`
+	HTML    string `json:"html"`
+}
+
+// BuildLogStream is a handler to push the new lines of the running build with Server-Sent Events.
+// The stream starts from ?from=<line> (or Last-Event-ID when the browser reconnects)
+// and is finished with "end" event when the build is finished.
+// The build is checked before it's tailed, so the users not allowed by the rules can't make the tailer poll it.
+func BuildLogStream(
+	client *builds.Client, tailer *builds.Tailer, rules access.Builds,
+	logger logrus.FieldLogger, pages *views.Registry, errorPages *ErrorPages,
+) router.Handle {
+	return func(c *router.Control) {
+		flusher, ok := c.Writer.(http.Flusher)
+		if !ok {
+			errorPages.Render(c, NewError(http.StatusInternalServerError, "Streaming is not supported", nil))
+			return
+		}
+
+		uuid := c.Get(":uuid")
+		logger := logger.WithField("build", uuid)
+
+		from, _ := strconv.Atoi(c.Get("from"))
+		if lastEventID, err := strconv.Atoi(c.Request.Header.Get("Last-Event-ID")); err == nil {
+			from = lastEventID
+		}
+
+		build, err := client.Results(uuid, 0)
+		if err == builds.ErrNotFound {
+			errorPages.Render(c, NewError(http.StatusNotFound, "The build is not found", nil))
+			return
+		}
+		if err != nil {
+			errorPages.Render(c, NewError(http.StatusBadGateway, "Couldn't get the build, please try again later", err))
+			return
+		}
+
+		source, login := sessionUser(c.Request)
+		if !rules.CanView(source, login, build.Username, build.Repository) {
+			// Don't tell the build exists
+			errorPages.Render(c, NewError(http.StatusNotFound, "The build is not found", nil))
+			return
+		}
+
+		lang := language(c, pages)
+		updates, unsubscribe := tailer.Subscribe(uuid, from)
+		defer unsubscribe()
+
+		var first builds.Update
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case first, ok = <-updates:
+		}
+		if !ok {
+			errorPages.Render(c, NewError(http.StatusNotFound, "The build is not found", nil))
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		// Disable buffering of nginx (Ingress)
+		header.Set("X-Accel-Buffering", "no")
+		c.Writer.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepAliveTicker := time.NewTicker(keepAlive)
+		defer keepAliveTicker.Stop()
+
+		// send writes the update, false is returned if the stream should be finished
+		send := func(update builds.Update) bool {
+			// The lines are rendered with "log-line" template of the build page
+			t, err := pages.Lookup(lang, "build-results")
+			if err != nil {
+				logger.Errorf("Couldn't render build log: %+v", err)
+				return false
+			}
+			if err = writeLogUpdate(c.Writer, t, update); err != nil {
+				logger.Infof("Couldn't write build log: %+v", err)
+				return false
+			}
+			flusher.Flush()
+
+			return !update.Build.Final()
+		}
+
+		if !send(first) {
+			return
+		}
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-keepAliveTicker.C:
+				fmt.Fprint(c.Writer, ": keep-alive\n\n")
+				flusher.Flush()
+			case update, ok := <-updates:
+				if !ok {
+					// The tailer is closed or the viewer is too slow, the browser reconnects
+					return
+				}
+				if !send(update) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeLogUpdate writes the new lines rendered as HTML, the event is "end" if the build is finished
+func writeLogUpdate(w http.ResponseWriter, t *template.Template, update builds.Update) error {
+	if len(update.Lines) > 0 {
+		// The browser reconnects from the last line since it may be unfinished
+		fmt.Fprintf(w, "id: %d\n", update.Lines[len(update.Lines)-1].Number)
+	}
+
+	data := struct {
+		Lines  []logLine `json:"lines"`
+		State  string    `json:"state"`
+		Passed bool      `json:"passed"`
+	}{
+		Lines:  make([]logLine, 0, len(update.Lines)),
+		State:  update.Build.Status(),
+		Passed: update.Build.Passed,
+	}
+	for _, line := range update.Lines {
+		var b bytes.Buffer
+		if err := t.ExecuteTemplate(&b, "log-line", line); err != nil {
+			return err
+		}
+		data.Lines = append(data.Lines, logLine{Number: line.Number, Section: line.Section, HTML: b.String()})
+	}
+
+	event := "log"
+	if update.Build.Final() {
+		event = "end"
+	}
+
+	return writeEvent(w, event, data)
+}
+
+// sessionUser returns the source and the login of the signed in user, they are empty for the guests
+func sessionUser(r *http.Request) (source, login string) {
+	sessionData := session.Get(r)
+	if sessionData == nil {
+		return "", ""
+	}
+
+	return sessionData.CAttr("Source").(string), sessionData.CAttr("Login").(string)
+}
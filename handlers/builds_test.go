@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/k8s-community/ui/buildlog"
+	"github.com/k8s-community/ui/builds"
+	"github.com/k8s-community/ui/views"
+)
+
+func TestBuildLogRendering(t *testing.T) {
+	pages, err := views.New("../templates", logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := "travis_fold:start:test\n\x1b[31m<script>alert(1)</script>\x1b[0m\ntravis_fold:end:test\n<b>done</b>"
+	lines := buildlog.Parse(log)
+	data := struct {
+		Build      *builds.Results
+		Sections   []*buildlog.Section
+		LastLine   int
+		BuildsLink string
+	}{
+		Build:    &builds.Results{Build: builds.Build{UUID: "1", Repository: "ui", Username: "alice"}, Log: log},
+		Sections: buildlog.Sections(lines),
+		LastLine: lines[len(lines)-1].Number,
+	}
+
+	var page bytes.Buffer
+	if err = pages.Render(&page, "en", "build-results", data); err != nil {
+		t.Fatalf("Render() = %v", err)
+	}
+	html := page.String()
+
+	for _, want := range []string{
+		`<details class="log-section" data-section="test"`,
+		`<div class="log-line" id="L1"><a class="log-number" href="#L1">1</a>` +
+			`<span class="ansi-fg-1">&lt;script&gt;alert(1)&lt;/script&gt;</span></div>`,
+		`<span class="">&lt;b&gt;done&lt;/b&gt;</span>`,
+		`data-from="2"`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("build page doesn't contain %s", want)
+		}
+	}
+	for _, unwanted := range []string{"<script>alert", "<b>done", "travis_fold", "\x1b"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("build page contains %q", unwanted)
+		}
+	}
+}
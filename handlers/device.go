@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/icza/session"
+	"github.com/takama/router"
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/devices"
+	"github.com/k8s-community/ui/models"
+	"github.com/k8s-community/ui/tokens"
+	"github.com/k8s-community/ui/views"
+)
+
+// devicePath is the path of the verification page where the users approve the sign in of the devices
+const devicePath = "/device"
+
+// deviceTokenScopes are the scopes of the access tokens issued to the devices
+var deviceTokenScopes = []string{tokens.ScopeCredentials}
+
+// deviceTokenTTL is the lifetime of the access tokens issued to the devices,
+// the CLI downloads the credentials right after the sign in and doesn't keep the token
+const deviceTokenTTL = time.Hour
+
+// Device is a handler set of the device authorization grant (RFC 8628), it allows to sign in the CLI
+// on the machines without a browser: the CLI shows the user code, the user approves the sign in
+// on the verification page from any other device, and the CLI gets a personal access token
+type Device struct {
+	db          *reform.DB
+	log         logrus.FieldLogger
+	pages       *views.Registry
+	errorPages  *ErrorPages
+	signInLinks []SignInLink
+	publicURL   string
+	ttl         time.Duration
+	interval    time.Duration
+}
+
+// NewDevice creates Device handler set:
+// - signInLinks are shown to the guests on the verification page
+// - publicURL is the base URL of the site, the verification URI given to the device is built from it
+// - ttl is the time given to the user to approve the sign in
+// - interval is the minimum time between the requests of the device polling for the result
+func NewDevice(
+	db *reform.DB, log logrus.FieldLogger, pages *views.Registry, errorPages *ErrorPages,
+	signInLinks []SignInLink, publicURL string, ttl, interval time.Duration,
+) *Device {
+	return &Device{
+		db:          db,
+		log:         log,
+		pages:       pages,
+		errorPages:  errorPages,
+		signInLinks: signInLinks,
+		publicURL:   strings.TrimSuffix(publicURL, "/"),
+		ttl:         ttl,
+		interval:    interval,
+	}
+}
+
+// Code is a handler to start the sign in of the device: POST /oauth/device/code
+// The number of the sign ins started from the same address and not finished yet is limited.
+func (h *Device) Code(c *router.Control) {
+	deviceCode, authorization, err := devices.Start(h.db, clientIP(c.Request), h.ttl, h.interval)
+	if err == devices.ErrTooMany {
+		c.Writer.Header().Set("Retry-After", strconv.Itoa(int(h.ttl/time.Second)))
+		h.errorPages.Render(c, NewError(
+			http.StatusTooManyRequests, "Too many sign ins of the CLI have been started, please try again later", err,
+		))
+		return
+	}
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't start device authorization: %v", err))
+		return
+	}
+
+	verificationURI := h.publicURL + devicePath
+	c.Writer.Header().Set("Cache-Control", "no-store")
+	c.Code(http.StatusOK).Body(struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}{
+		DeviceCode:              deviceCode,
+		UserCode:                authorization.UserCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(authorization.UserCode),
+		ExpiresIn:               int(h.ttl / time.Second),
+		Interval:                authorization.PollInterval,
+	})
+}
+
+// Token is a handler of the device polling for the result: POST /oauth/device/token
+// The approved device gets a personal access token, the errors are returned as defined by RFC 8628.
+func (h *Device) Token(c *router.Control) {
+	if c.Request.PostFormValue("grant_type") != devices.GrantType {
+		writeOAuthError(c, "unsupported_grant_type")
+		return
+	}
+
+	var token string
+	authorization, err := devices.Poll(
+		h.db, c.Request.PostFormValue("device_code"),
+		func(tx *reform.TX, authorization *models.DeviceAuthorization) (err error) {
+			name := "CLI signed in on " + time.Now().UTC().Format("2006-01-02 15:04 MST")
+			token, _, err = tokens.Create(tx.Querier, *authorization.UserID, name, deviceTokenScopes, deviceTokenTTL)
+			if err != nil {
+				return fmt.Errorf("couldn't create access token of user %d: %v", *authorization.UserID, err)
+			}
+			return nil
+		},
+	)
+	switch err {
+	case nil:
+	case devices.ErrAuthorizationPending, devices.ErrSlowDown, devices.ErrAccessDenied,
+		devices.ErrExpired, devices.ErrInvalidGrant:
+		writeOAuthError(c, err.Error())
+		return
+	default:
+		h.errorPages.Render(c, fmt.Errorf("couldn't poll device authorization: %v", err))
+		return
+	}
+
+	logger := h.log.WithField("user_id", *authorization.UserID)
+	logger.Infof("Device was signed in with user code %s", authorization.UserCode)
+	c.Writer.Header().Set("Cache-Control", "no-store")
+	c.Code(http.StatusOK).Body(struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+		Scope       string `json:"scope"`
+	}{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(deviceTokenTTL / time.Second),
+		Scope:       deviceTokenScopes[0],
+	})
+}
+
+// devicePage is the data of the verification page
+type devicePage struct {
+	Login       string
+	SignInLinks []SignInLink
+	Next        string // the guests return to the page after sign in
+	UserCode    string
+	CSRF        string
+
+	Approved bool
+	Denied   bool
+	Invalid  bool // the user code is invalid
+}
+
+// Page is a handler of the verification page: GET /device?user_code=<code>
+func (h *Device) Page(c *router.Control) {
+	data := &devicePage{UserCode: devices.NormalizeUserCode(c.Get("user_code"))}
+
+	sessionData := session.Get(c.Request)
+	if sessionData == nil {
+		data.SignInLinks = h.signInLinks
+		data.Next = devicePath
+		if data.UserCode != "" {
+			data.Next += "?user_code=" + url.QueryEscape(data.UserCode)
+		}
+	} else {
+		data.Login = sessionData.CAttr("Login").(string)
+		data.CSRF = csrfToken(sessionData)
+	}
+
+	h.render(c, http.StatusOK, data)
+}
+
+// Decide is a handler of the user's decision on the verification page: POST /device
+func (h *Device) Decide(c *router.Control) {
+	sessionData := session.Get(c.Request)
+	if sessionData == nil {
+		http.Redirect(c.Writer, c.Request, devicePath, http.StatusSeeOther)
+		return
+	}
+
+	csrf := c.Request.PostFormValue("csrf")
+	if !validCSRF(csrf, sessionData) {
+		h.errorPages.Render(c, NewError(
+			http.StatusForbidden, "The form has expired, please try again", fmt.Errorf("wrong CSRF token %q", csrf),
+		))
+		return
+	}
+
+	login := sessionData.CAttr("Login").(string)
+	user, err := findUser(h.db, sessionData.CAttr("Source").(string), login)
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't get user %s from DB: %v", login, err))
+		return
+	}
+
+	data := &devicePage{
+		Login:    login,
+		UserCode: devices.NormalizeUserCode(c.Request.PostFormValue("user_code")),
+		CSRF:     csrfToken(sessionData),
+	}
+
+	if c.Request.PostFormValue("action") == "approve" {
+		err = devices.Approve(h.db, data.UserCode, user.ID)
+		data.Approved = err == nil
+	} else {
+		err = devices.Deny(h.db, data.UserCode, user.ID)
+		data.Denied = err == nil
+	}
+	if err == devices.ErrInvalid {
+		data.Invalid = true
+		h.render(c, http.StatusBadRequest, data)
+		return
+	}
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't decide on device authorization of user %s: %v", login, err))
+		return
+	}
+
+	if data.Approved {
+		h.log.WithField("user", login).Infof("Sign in of device with user code %s was approved", data.UserCode)
+	} else {
+		h.log.WithField("user", login).Infof("Sign in of device with user code %s was denied", data.UserCode)
+	}
+	h.render(c, http.StatusOK, data)
+}
+
+// render writes the verification page
+func (h *Device) render(c *router.Control, status int, data *devicePage) {
+	lang := language(c, h.pages)
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Cache-Control", "no-store")
+	c.Writer.WriteHeader(status)
+	if err := h.pages.Render(c.Writer, lang, "device", data); err != nil {
+		h.log.Errorf("Couldn't render device page: %+v", err)
+	}
+}
+
+// writeOAuthError writes the error of the token request as defined by OAuth 2.0 (RFC 6749, section 5.2)
+func writeOAuthError(c *router.Control, code string) {
+	c.Writer.Header().Set("Cache-Control", "no-store")
+	c.Code(http.StatusBadRequest).Body(struct {
+		Error string `json:"error"`
+	}{code})
+}
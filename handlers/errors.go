@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/takama/router"
+
+	"github.com/k8s-community/ui/views"
+)
+
+// Error is the failure of the request. The message is shown to the user,
+// the cause is only logged since it may contain internal details.
+type Error struct {
+	Status  int    // HTTP status code
+	Message string // the status text is shown if it's empty
+	Cause   error
+}
+
+// NewError creates the error with the status, the message for the user and the internal cause (may be nil)
+func NewError(status int, message string, cause error) *Error {
+	return &Error{Status: status, Message: message, Cause: cause}
+}
+
+func (e *Error) Error() string {
+	text := fmt.Sprintf("%d %s", e.Status, e.Message)
+	if e.Cause != nil {
+		text += ": " + e.Cause.Error()
+	}
+
+	return text
+}
+
+// ErrorPages shows the errors to the users with the request ID, the same ID is logged with the cause
+type ErrorPages struct {
+	log   logrus.FieldLogger
+	pages *views.Registry
+}
+
+// NewErrorPages creates ErrorPages rendered with "error" template
+func NewErrorPages(log logrus.FieldLogger, pages *views.Registry) *ErrorPages {
+	return &ErrorPages{log: log, pages: pages}
+}
+
+// Render logs the error and writes the error page, the errors which aren't *Error are internal ones.
+// The clients which accept JSON (and not HTML) and the requests to the API get the error as JSON.
+func (p *ErrorPages) Render(c *router.Control, err error) {
+	e, ok := err.(*Error)
+	if !ok {
+		e = NewError(http.StatusInternalServerError, "", err)
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(e.Status)
+	}
+
+	id := requestID(c.Request)
+	logger := p.log.WithFields(logrus.Fields{"request": id, "status": e.Status, "path": c.Request.URL.Path})
+	switch {
+	case e.Status >= http.StatusInternalServerError && e.Cause != nil:
+		logger.Errorf("Request failed: %s: %+v", e.Message, e.Cause)
+	case e.Status >= http.StatusInternalServerError:
+		logger.Errorf("Request failed: %s", e.Message)
+	case e.Cause != nil:
+		logger.Infof("Request failed: %s: %+v", e.Message, e.Cause)
+	}
+
+	header := c.Writer.Header()
+	header.Set("Cache-Control", "no-store")
+
+	if wantsJSON(c.Request) {
+		header.Set("Content-Type", "application/json")
+		c.Writer.WriteHeader(e.Status)
+		json.NewEncoder(c.Writer).Encode(struct {
+			Error     string `json:"error"`
+			RequestID string `json:"requestId"`
+		}{e.Message, id})
+		return
+	}
+
+	lang := language(c, p.pages)
+	data := struct {
+		Status     int
+		StatusText string
+		Message    string
+		RequestID  string
+	}{
+		Status:     e.Status,
+		StatusText: p.pages.Translate(lang, http.StatusText(e.Status)),
+		Message:    p.pages.Translate(lang, e.Message),
+		RequestID:  id,
+	}
+
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	c.Writer.WriteHeader(e.Status)
+	if err := p.pages.Render(c.Writer, lang, "error", data); err != nil {
+		logger.Errorf("Couldn't render error page: %+v", err)
+	}
+}
+
+// NotFound is a handler of undefined routes
+func (p *ErrorPages) NotFound(c *router.Control) {
+	p.Render(c, NewError(http.StatusNotFound, "", fmt.Errorf("couldn't find path %s", c.Request.RequestURI)))
+}
+
+// Recover is a wrapper of the handlers (the router's CustomHandler) which recovers from their panics,
+// the panic value and the stack are logged with the request ID
+func (p *ErrorPages) Recover(next router.Handle) router.Handle {
+	return func(c *router.Control) {
+		defer func() {
+			if rcv := recover(); rcv != nil {
+				p.log.WithField("request", requestID(c.Request)).Errorf("panic: %v\n%s", rcv, debug.Stack())
+				p.Render(c, NewError(http.StatusInternalServerError, "", nil))
+			}
+		}()
+
+		next(c)
+	}
+}
+
+// Panic is a handler of the panics recovered by the router out of the handlers wrapped by Recover,
+// the router doesn't give the panic value, so only the stack is logged
+func (p *ErrorPages) Panic(c *router.Control) {
+	p.log.WithField("request", requestID(c.Request)).Errorf("Recovered from panic:\n%s", debug.Stack())
+	p.Render(c, NewError(http.StatusInternalServerError, "", nil))
+}
+
+// wantsJSON tells if the error should be returned as JSON
+func wantsJSON(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, apiPrefix) {
+		return true
+	}
+
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/takama/router"
+
+	"github.com/k8s-community/ui/views"
+)
+
+// entries is the hook keeping the logged entries
+type entries []*logrus.Entry
+
+func (e *entries) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (e *entries) Fire(entry *logrus.Entry) error {
+	*e = append(*e, entry)
+	return nil
+}
+
+func TestRecover(t *testing.T) {
+	pages, err := views.New("../templates", logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	log.Out = ioutil.Discard
+	logged := &entries{}
+	log.Hooks.Add(logged)
+	errorPages := NewErrorPages(log, pages)
+
+	r := router.New()
+	r.CustomHandler = errorPages.Recover
+	r.GET("/panic", func(c *router.Control) {
+		panic("nil map of user alice")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(RequestIDHeader, "request-1")
+	w := httptest.NewRecorder()
+	RequestID(r).ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if strings.Contains(w.Body.String(), "nil map") {
+		t.Error("error page contains the panic value")
+	}
+
+	var found bool
+	for _, entry := range *logged {
+		if strings.HasPrefix(entry.Message, "panic: nil map of user alice\n") && entry.Data["request"] == "request-1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("the panic value isn't logged with the request ID")
+	}
+}
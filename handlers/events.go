@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/icza/session"
+	"github.com/takama/router"
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/models"
+)
+
+// keepAlive is the interval of comments sent to the idle stream, so the proxies don't close it
+const keepAlive = 15 * time.Second
+
+// ProvisioningStatus is the state of the user's Kubernetes environment shown on the home page
+type ProvisioningStatus struct {
+	State          string `json:"state"` // state of the provisioning job, empty if there is no job
+	Activated      bool   `json:"activated"`
+	HasError       bool   `json:"hasError"`
+	HasCredentials bool   `json:"hasCredentials"` // token and cert are ready
+}
+
+// final tells if the status won't change anymore
+func (s ProvisioningStatus) final() bool {
+	return s.HasError || (s.Activated && s.HasCredentials)
+}
+
+// Events is a handler set to notify the signed in user about the provisioning of the environment
+type Events struct {
+	db         *reform.DB
+	log        logrus.FieldLogger
+	errorPages *ErrorPages
+	interval   time.Duration
+
+	done  chan struct{}
+	close sync.Once
+}
+
+// NewEvents creates Events handler set, the status is checked in DB with the interval
+func NewEvents(db *reform.DB, log logrus.FieldLogger, errorPages *ErrorPages, interval time.Duration) *Events {
+	return &Events{
+		db:         db,
+		log:        log,
+		errorPages: errorPages,
+		interval:   interval,
+		done:       make(chan struct{}),
+	}
+}
+
+// Stream is a handler to push the changes of the provisioning status with Server-Sent Events.
+// The stream is finished when the status is final.
+func (h *Events) Stream(c *router.Control) {
+	sessionData := session.Get(c.Request)
+	if sessionData == nil {
+		h.errorPages.Render(c, NewError(http.StatusUnauthorized, "", nil))
+		return
+	}
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		h.errorPages.Render(c, NewError(http.StatusInternalServerError, "Streaming is not supported", nil))
+		return
+	}
+
+	login := sessionData.CAttr("Login").(string)
+	source := sessionData.CAttr("Source").(string)
+	logger := h.log.WithField("user", login)
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// Disable buffering of nginx (Ingress)
+	header.Set("X-Accel-Buffering", "no")
+	c.Writer.WriteHeader(http.StatusOK)
+
+	// The client should reconnect not earlier than the next check
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", h.interval/time.Millisecond)
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	var last *ProvisioningStatus
+	lastWrite := time.Now()
+
+	for {
+		status, err := h.status(source, login)
+		if err != nil {
+			logger.Errorf("Couldn't get provisioning status: %+v", err)
+		} else if last == nil || *status != *last {
+			if err = writeEvent(c.Writer, "status", status); err != nil {
+				logger.Infof("Couldn't write provisioning status: %+v", err)
+				return
+			}
+			last = status
+			lastWrite = time.Now()
+		} else if time.Since(lastWrite) >= keepAlive {
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+			lastWrite = time.Now()
+		}
+		flusher.Flush()
+
+		if last != nil && last.final() {
+			return
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-h.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status is a handler to get the provisioning status as JSON,
+// it's a fallback for the browsers which don't support Server-Sent Events
+func (h *Events) Status(c *router.Control) {
+	sessionData := session.Get(c.Request)
+	if sessionData == nil {
+		h.errorPages.Render(c, NewError(http.StatusUnauthorized, "", nil))
+		return
+	}
+
+	login := sessionData.CAttr("Login").(string)
+	source := sessionData.CAttr("Source").(string)
+
+	status, err := h.status(source, login)
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't get provisioning status of user %s: %v", login, err))
+		return
+	}
+
+	c.Writer.Header().Set("Cache-Control", "no-store")
+	c.Code(http.StatusOK).Body(status)
+}
+
+// Close finishes the open streams, it should be called on shutdown
+// since the server doesn't wait for them to be idle
+func (h *Events) Close() {
+	h.close.Do(func() {
+		close(h.done)
+	})
+}
+
+// status returns the provisioning status of the user
+func (h *Events) status(source, login string) (*ProvisioningStatus, error) {
+	return provisioningStatus(h.db, source, login)
+}
+
+// provisioningStatus returns the provisioning status of the user kept in DB
+func provisioningStatus(db *reform.DB, source, login string) (*ProvisioningStatus, error) {
+	st, err := db.SelectOneFrom(models.UserTable, "WHERE source = $1 AND name = $2", source, login)
+	if err == reform.ErrNoRows {
+		return &ProvisioningStatus{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user := st.(*models.User)
+
+	st, err = db.SelectOneFrom(models.ProvisioningJobTable, "WHERE user_id = $1", user.ID)
+	if err == reform.ErrNoRows {
+		return newProvisioningStatus(user, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newProvisioningStatus(user, st.(*models.ProvisioningJob)), nil
+}
+
+// newProvisioningStatus returns the provisioning status of the user with the job (nil if there is no job)
+func newProvisioningStatus(user *models.User, job *models.ProvisioningJob) *ProvisioningStatus {
+	status := &ProvisioningStatus{
+		HasCredentials: user.Token != nil && *user.Token != "" && user.Cert != nil && *user.Cert != "",
+	}
+
+	if job == nil {
+		// The users provisioned before the jobs were introduced
+		status.Activated = status.HasCredentials
+		return status
+	}
+
+	status.State = job.State
+	status.Activated = job.State == models.JobSucceeded
+	status.HasError = job.State == models.JobFailed
+
+	return status
+}
+
+// writeEvent writes the data as JSON encoded event
+func writeEvent(w http.ResponseWriter, event string, data interface{}) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, content)
+	return err
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/takama/router"
+)
+
+// Health is a handler set to report the service health
+type Health struct {
+	shuttingDown int32
+}
+
+// Healthz reports if the service is able to handle requests.
+// It fails as soon as shutdown begins, so Kubernetes stops routing traffic to the instance.
+func (h *Health) Healthz(c *router.Control) {
+	if atomic.LoadInt32(&h.shuttingDown) == 1 {
+		c.Code(http.StatusServiceUnavailable).Body(http.StatusText(http.StatusServiceUnavailable))
+		return
+	}
+
+	c.Code(http.StatusOK).Body(http.StatusText(http.StatusOK))
+}
+
+// Shutdown marks the service as shutting down
+func (h *Health) Shutdown() {
+	atomic.StoreInt32(&h.shuttingDown, 1)
+}
@@ -2,42 +2,53 @@ package handlers
 
 import (
 	"html/template"
+	"net"
 	"net/http"
 
 	"strings"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/icza/session"
+	"github.com/k8s-community/ui/access"
+	"github.com/k8s-community/ui/invitations"
 	"github.com/k8s-community/ui/models"
+	"github.com/k8s-community/ui/views"
 	"github.com/takama/router"
 	"gopkg.in/reform.v1"
 )
 
-// Home handles homepage request
-func Home(db *reform.DB, log logrus.FieldLogger, k8sToken string) router.Handle {
-	lang := "en"
+// Home handles homepage request, it also serves /join/:code links with invitation codes
+func Home(
+	db *reform.DB, log logrus.FieldLogger, pages *views.Registry, errorPages *ErrorPages,
+	k8sToken string, signInLinks []SignInLink, invitationRequired, buildHistory bool, roles access.Roles,
+) router.Handle {
 	return func(c *router.Control) {
-		t, err := template.ParseFiles(
-			"templates/"+lang+"/layout.html",
-			"templates/"+lang+"/index.html",
-		)
+		data := struct {
+			SignInLinks    []SignInLink  // links to sign in with the identity providers
+			SignOutLink    string        // link to sign out (delete session)
+			Login          string        // user's login
+			Activated      bool          // is user activated in k8s
+			HasError       bool          // couldn't activate user in k8s
+			GuestToken     string        // a token to reach Kubernetes
+			Token          string        // personal token
+			CA             template.HTML // personal cert
+			KubeconfigLink string        // link to download kubeconfig
+			BuildsLink     string        // link to the build history, empty if it's disabled
+			AdminLink      string        // link to the dashboard, empty for the participants
 
-		if err != nil {
-			log.Fatalf("Couldn't parse template files: %+v", err)
+			InvitationRequired bool   // invitation code is required to enroll
+			Invitation         string // valid invitation code presented by the user
+			InvitationError    bool   // presented invitation code is invalid
+		}{
+			SignInLinks:        signInLinks,
+			InvitationRequired: invitationRequired,
+			SignOutLink:        "/signout",
+			KubeconfigLink:     "/kubeconfig",
+			GuestToken:         k8sToken,
 		}
 
-		data := struct {
-			GitHubSignInLink string        // link to sign in to GitHub
-			SignOutLink      string        // link to sign out (delete session)
-			Login            string        // user's login
-			Activated        bool          // is user activated in k8s
-			GuestToken       string        // a token to reach Kubernetes
-			Token            string        // personal token
-			CA               template.HTML // personal cert
-		}{
-			GitHubSignInLink: "/oauth/github",
-			SignOutLink:      "/signout",
-			GuestToken:       k8sToken,
+		if buildHistory {
+			data.BuildsLink = "/builds"
 		}
 
 		// Check if user have already logged in
@@ -45,33 +56,69 @@ func Home(db *reform.DB, log logrus.FieldLogger, k8sToken string) router.Handle
 		if sessionData != nil {
 			data.Login = sessionData.CAttr("Login").(string)
 			data.Activated = sessionData.Attr("Activated").(bool)
+			data.HasError = sessionData.Attr("HasError").(bool)
+			if roles.Role(sessionData.CAttr("Source").(string), data.Login) != access.RoleParticipant {
+				data.AdminLink = adminPath
+			}
+
+			token, cert := GetToken(db, log, sessionData.CAttr("Source").(string), data.Login)
+			data.Token = token
+			data.CA = template.HTML(strings.Replace(cert, "\n", "<br>", -1))
+		} else if code := invitationCode(c); code != "" {
+			if invitation, err := invitations.Find(db, code); err == nil {
+				data.Invitation = invitation.Code
+			} else if err == invitations.ErrInvalid {
+				data.InvitationError = true
+			} else {
+				log.Errorf("Couldn't check invitation code: %+v", err)
+			}
 		}
 
-		token, cert := GetToken(db, log, data.Login)
-		data.Token = token
-		data.CA = template.HTML(cert)
+		if err := pages.Render(c.Writer, language(c, pages), "index", data); err != nil {
+			log.Errorf("Couldn't render home page: %+v", err)
+		}
+	}
+}
 
-		t.ExecuteTemplate(c.Writer, "layout", data)
+// invitationCode returns the code from /join/:code link or from the form on the home page
+func invitationCode(c *router.Control) string {
+	if code := c.Get(":code"); code != "" {
+		return code
 	}
+
+	return c.Get("code")
 }
 
 func Signout() router.Handle {
 	return func(c *router.Control) {
-		session.Remove(session.Get(c.Request), c.Writer)
+		if sessionData := session.Get(c.Request); sessionData != nil {
+			session.Remove(sessionData, c.Writer)
+		}
 		http.Redirect(c.Writer, c.Request, "/", http.StatusFound)
 	}
 }
 
-// Handle undefined routes
-func NotFound(log logrus.FieldLogger) router.Handle {
-	return func(c *router.Control) {
-		log.Warningf("couldn't find path: %s", c.Request.RequestURI)
-		http.NotFound(c.Writer, c.Request)
+// clientIP returns the address of the client, the proxies (e.g. Ingress) are taken into account
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
 	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
+
+// localPath tells if the link leads to a page of the service, so it's safe to redirect to it
+func localPath(link string) bool {
+	return strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "//") && !strings.HasPrefix(link, "/\\")
 }
 
-func GetToken(db *reform.DB, logger logrus.FieldLogger, username string) (token string, cert string) {
-	st, err := db.FindOneFrom(models.UserTable, "name", username)
+// GetToken returns user's Kubernetes token and ca.crt stored in DB
+func GetToken(db *reform.DB, logger logrus.FieldLogger, source, username string) (token string, cert string) {
+	user, err := findUser(db, source, username)
 	if err == reform.ErrNoRows {
 		logger.Infof("Show user token and cert: attention! user '%s' not found", username)
 		return
@@ -82,14 +129,22 @@ func GetToken(db *reform.DB, logger logrus.FieldLogger, username string) (token
 		return
 	}
 
-	user := st.(*models.User)
 	if user.Token != nil {
 		token = *user.Token
 	}
 	if user.Cert != nil {
 		cert = *user.Cert
-		cert = strings.Replace(cert, "\n", "<br>", -1)
 	}
 
 	return token, cert
 }
+
+// findUser returns the user specified by the source and the login
+func findUser(db *reform.DB, source, login string) (*models.User, error) {
+	st, err := db.SelectOneFrom(models.UserTable, "WHERE source = $1 AND name = $2", source, login)
+	if err != nil {
+		return nil, err
+	}
+
+	return st.(*models.User), nil
+}
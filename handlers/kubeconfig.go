@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/icza/session"
+	"github.com/k8s-community/ui/kubeconfig"
+	"github.com/k8s-community/ui/models"
+	"github.com/takama/router"
+	"gopkg.in/reform.v1"
+)
+
+// Cluster describes Kubernetes cluster the users are working with
+type Cluster struct {
+	Name      string // cluster name used in kubeconfig
+	APIServer string // URL of Kubernetes API server
+}
+
+// Kubeconfig handles request to download kubeconfig of signed in user
+func Kubeconfig(db *reform.DB, log logrus.FieldLogger, errorPages *ErrorPages, cluster Cluster) router.Handle {
+	return func(c *router.Control) {
+		sessionData := session.Get(c.Request)
+		if sessionData == nil {
+			http.Redirect(c.Writer, c.Request, "/", http.StatusFound)
+			return
+		}
+
+		login := sessionData.CAttr("Login").(string)
+		user, err := findCredentials(db, sessionData.CAttr("Source").(string), login)
+		if err != nil {
+			errorPages.Render(c, err)
+			return
+		}
+
+		name := user.KubernetesName()
+		config, err := kubeconfig.New(cluster.Name, cluster.APIServer, name, *user.Token, *user.Cert).Marshal()
+		if err != nil {
+			errorPages.Render(c, fmt.Errorf("couldn't marshal kubeconfig of user %s: %v", login, err))
+			return
+		}
+
+		c.Writer.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.kubeconfig"`, name))
+		c.Writer.Header().Set("Cache-Control", "no-store")
+		c.ContentType = "application/x-yaml"
+		c.Code(http.StatusOK).Body(string(config))
+	}
+}
+
+// findCredentials returns the user with the prepared Kubernetes credentials,
+// *Error with 404 status is returned if they aren't prepared yet
+func findCredentials(db *reform.DB, source, login string) (*models.User, error) {
+	user, err := findUser(db, source, login)
+	if err != nil && err != reform.ErrNoRows {
+		return nil, fmt.Errorf("couldn't get user %s from DB: %v", login, err)
+	}
+
+	if err == reform.ErrNoRows || user.Token == nil || *user.Token == "" || user.Cert == nil || *user.Cert == "" {
+		return nil, NewError(
+			http.StatusNotFound, "Your credentials haven't been prepared yet",
+			fmt.Errorf("credentials of user %s were requested, but they are not prepared yet", login),
+		)
+	}
+
+	return user, nil
+}
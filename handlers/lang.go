@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/takama/router"
+
+	"github.com/k8s-community/ui/views"
+)
+
+// langCookie keeps the language chosen by the user
+const langCookie = "k8s-community-lang"
+
+// langCookieMaxAge is the time the chosen language is remembered
+const langCookieMaxAge = 365 * 24 * time.Hour
+
+// language returns the language of the pages for the request: the one chosen with ?lang=
+// (it's remembered in the cookie), the one remembered before or the one preferred by the browser.
+// It should be called before the header is written, otherwise the cookie is lost.
+func language(c *router.Control, pages *views.Registry) string {
+	supported := pages.Langs()
+
+	if lang := c.Get("lang"); views.Supported(lang, supported) {
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     langCookie,
+			Value:    lang,
+			Path:     "/",
+			MaxAge:   int(langCookieMaxAge / time.Second),
+			HttpOnly: true,
+		})
+		return lang
+	}
+
+	if cookie, err := c.Request.Cookie(langCookie); err == nil && views.Supported(cookie.Value, supported) {
+		return cookie.Value
+	}
+
+	return views.Negotiate(c.Request.Header.Get("Accept-Language"), supported)
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/icza/session"
+	"github.com/takama/router"
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/access"
+	"github.com/k8s-community/ui/invitations"
+	"github.com/k8s-community/ui/models"
+	"github.com/k8s-community/ui/providers"
+	"github.com/k8s-community/ui/provisioning"
+	"github.com/k8s-community/ui/views"
+)
+
+// callbackSuffix marks the callback routes: /oauth/<provider>-cb
+const callbackSuffix = "-cb"
+
+// SignInLink is a link to sign in with the provider shown on the home page
+type SignInLink struct {
+	Title string
+	URL   string
+}
+
+// OAuth is a handler set to sign in the users with the identity providers
+type OAuth struct {
+	providers          []providers.Provider
+	rules              access.Rules
+	invitationRequired bool // invitation code is required to enroll
+	states             *OAuthState
+	db                 *reform.DB
+	log                logrus.FieldLogger
+	pages              *views.Registry
+	errorPages         *ErrorPages
+	provisioning       *provisioning.Queue
+}
+
+// NewOAuth create new OAuth handler set:
+// - queue runs the jobs to create the user's environment in Kubernetes
+// - states generates per-request states to protect the user from CSRF attacks
+// - rules restrict the users allowed to sign in, the rejections are recorded in DB
+// - invitationRequired defines if the users have to present an invitation code to enroll
+// - providers are the identity providers available to sign in with
+func NewOAuth(
+	log logrus.FieldLogger, pages *views.Registry, errorPages *ErrorPages, db *reform.DB, queue *provisioning.Queue, states *OAuthState,
+	rules access.Rules, invitationRequired bool, providers ...providers.Provider,
+) *OAuth {
+	return &OAuth{
+		providers:          providers,
+		rules:              rules,
+		invitationRequired: invitationRequired,
+		states:             states,
+		db:                 db,
+		log:                log,
+		pages:              pages,
+		errorPages:         errorPages,
+		provisioning:       queue,
+	}
+}
+
+// SignInLinks returns the links to sign in with the providers
+func (h *OAuth) SignInLinks() []SignInLink {
+	links := make([]SignInLink, 0, len(h.providers))
+	for _, provider := range h.providers {
+		links = append(links, SignInLink{Title: provider.Title(), URL: "/oauth/" + provider.Name()})
+	}
+
+	return links
+}
+
+// Handle is a handler of /oauth/:provider route, it serves both the login and the callback
+// (/oauth/<provider>-cb) since the router doesn't allow them to be separate routes
+func (h *OAuth) Handle(c *router.Control) {
+	name := c.Get(":provider")
+	callback := strings.HasSuffix(name, callbackSuffix)
+	name = strings.TrimSuffix(name, callbackSuffix)
+
+	for _, provider := range h.providers {
+		if provider.Name() != name {
+			continue
+		}
+
+		if callback {
+			h.callback(c, provider)
+		} else {
+			h.login(c, provider)
+		}
+		return
+	}
+
+	h.errorPages.Render(c, NewError(http.StatusNotFound, "", fmt.Errorf("unknown OAuth provider %s", name)))
+}
+
+// providerError writes the error reported by the provider to the callback (RFC 6749, section 4.1.2.1).
+// The description is written by the provider (or by anyone who crafted the link), so it's only logged.
+func (h *OAuth) providerError(c *router.Control, provider providers.Provider, code, description string) {
+	cause := fmt.Errorf("%s returned error %s: %s", provider.Name(), code, description)
+
+	if code == "access_denied" {
+		h.errorPages.Render(c, NewError(http.StatusForbidden, "The sign in was cancelled, please sign in again", cause))
+		return
+	}
+
+	h.errorPages.Render(c, NewError(http.StatusBadRequest, "The sign in has failed, please sign in again", cause))
+}
+
+// login redirects to the provider's authorization page, the invitation code (if any)
+// and the page to return to (?next=) are kept with the state until the callback
+func (h *OAuth) login(c *router.Control, provider providers.Provider) {
+	signIn := SignIn{Invitation: c.Get("invitation")}
+	if next := c.Get("next"); localPath(next) {
+		signIn.Next = next
+	}
+
+	state, opts, err := h.states.New(c.Writer, provider.PKCE(), provider.Nonce(), signIn)
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't generate OAuth state: %v", err))
+		return
+	}
+
+	http.Redirect(c.Writer, c.Request, provider.AuthCodeURL(state, opts...), http.StatusTemporaryRedirect)
+}
+
+// callback processes authorization callback from the provider
+func (h *OAuth) callback(c *router.Control, provider providers.Provider) {
+	state := c.Get("state")
+	code := c.Get("code")
+	logger := h.log.WithField("provider", provider.Name())
+
+	opts, signIn, err := h.states.Consume(c.Writer, c.Request, state)
+	if err != nil {
+		h.errorPages.Render(c, NewError(
+			http.StatusBadRequest, "Your sign in attempt has expired, please sign in again",
+			fmt.Errorf("wrong state %s with code %s from %s: %v", state, code, provider.Name(), err),
+		))
+		return
+	}
+
+	// The provider reports the errors instead of the code, e.g. if the user declines the authorization
+	if providerError := c.Get("error"); providerError != "" {
+		h.providerError(c, provider, providerError, c.Get("error_description"))
+		return
+	}
+
+	identity, err := provider.Identity(providers.WithNonce(context.Background(), signIn.Nonce), code, opts...)
+	if err != nil {
+		h.errorPages.Render(c, NewError(
+			http.StatusBadGateway, fmt.Sprintf("Couldn't get your account from %s, please sign in again", provider.Title()),
+			fmt.Errorf("couldn't get user for code %s from %s: %v", code, provider.Name(), err),
+		))
+		return
+	}
+
+	login := identity.Login
+	logger = logger.WithField("user", login)
+	logger.Info("User was authorized in oauth-proxy")
+
+	if err = h.rules.Check(provider.Source(), identity); err != nil {
+		logger.Warningf("Sign in was rejected: %+v", err)
+		h.reject(c, provider, login, err)
+		return
+	}
+
+	if err = h.enroll(provider, login, signIn.Invitation); err != nil {
+		if _, ok := err.(*access.Denied); ok {
+			logger.Warningf("Sign in was rejected: %+v", err)
+			h.reject(c, provider, login, err)
+		} else {
+			h.errorPages.Render(c, fmt.Errorf("couldn't check invitation code of user %s: %v", login, err))
+		}
+		return
+	}
+
+	sessionData := session.NewSessionOptions(&session.SessOptions{
+		CAttrs: map[string]interface{}{
+			"Login":     login,
+			"Source":    provider.Source(),
+			"UserAgent": c.Request.UserAgent(),
+			"IP":        clientIP(c.Request),
+		},
+		Attrs: map[string]interface{}{"Activated": false, "HasError": false},
+	})
+	session.Add(sessionData, c.Writer)
+
+	// The user's environment is created in background, the job is retried if user-manager fails
+	if err = h.provisioning.Enqueue(provider.Source(), login); err != nil {
+		logger.Errorf("Couldn't enqueue provisioning job: %+v", err)
+	}
+
+	next := "/"
+	if signIn.Next != "" {
+		next = signIn.Next
+	}
+	http.Redirect(c.Writer, c.Request, next, http.StatusMovedPermanently)
+}
+
+// enroll redeems the invitation code. If the codes are required, *access.Denied error is returned
+// for the user who presented no valid code and hasn't been enrolled before.
+func (h *OAuth) enroll(provider providers.Provider, login, invitation string) error {
+	if invitation != "" {
+		err := invitations.Redeem(h.db, invitation, provider.Source(), login)
+		if err == nil {
+			return nil
+		}
+		if err != invitations.ErrInvalid {
+			return err
+		}
+		if h.invitationRequired {
+			return &access.Denied{Reason: err.Error()}
+		}
+		h.log.WithField("user", login).Warningf("Invitation code %s wasn't redeemed: %+v", invitation, err)
+	}
+
+	if !h.invitationRequired {
+		return nil
+	}
+
+	enrolled, err := invitations.Enrolled(h.db, provider.Source(), login)
+	if err != nil {
+		return err
+	}
+	if !enrolled {
+		return &access.Denied{Reason: "invitation code is required"}
+	}
+
+	return nil
+}
+
+// reject records the rejected sign in and shows the denial page
+func (h *OAuth) reject(c *router.Control, provider providers.Provider, login string, reason error) {
+	rejection := &models.Rejection{
+		Source: provider.Source(),
+		Name:   login,
+		Reason: reason.Error(),
+	}
+	if userAgent := c.Request.UserAgent(); userAgent != "" {
+		rejection.UserAgent = &userAgent
+	}
+	if ip := clientIP(c.Request); ip != "" {
+		rejection.IP = &ip
+	}
+
+	if err := h.db.Insert(rejection); err != nil {
+		h.log.WithField("user", login).Errorf("Couldn't save sign in rejection: %+v", err)
+	}
+
+	data := struct {
+		Login              string // user's login
+		Provider           string // title of the provider the user signed in with
+		InvitationRequired bool   // invitation code is required to enroll
+	}{
+		Login:              login,
+		Provider:           provider.Title(),
+		InvitationRequired: h.invitationRequired,
+	}
+
+	lang := language(c, h.pages)
+	c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Writer.WriteHeader(http.StatusForbidden)
+	if err := h.pages.Render(c.Writer, lang, "denied", data); err != nil {
+		h.log.WithField("user", login).Errorf("Couldn't render denial page: %+v", err)
+	}
+}
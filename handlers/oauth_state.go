@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const oauthStateCookieName = "k8s-community-oauth-state"
+
+// OAuthState generates a random state for each login attempt and binds it to the browser
+// with a short-lived signed cookie, so the state can be verified and consumed once in the callback
+type OAuthState struct {
+	secret []byte
+	ttl    time.Duration
+	secure bool
+}
+
+// SignIn is the context of the sign in kept with the state until the callback
+type SignIn struct {
+	Invitation string // invitation code presented before sign in
+	Next       string // local path the user is redirected to after sign in
+	Nonce      string // nonce sent to the provider, it's generated by New and returned by Consume
+}
+
+// oauthStateData is the content of the state cookie
+type oauthStateData struct {
+	State      string `json:"s"`
+	Verifier   string `json:"v,omitempty"` // PKCE code verifier
+	Nonce      string `json:"o,omitempty"`
+	Invitation string `json:"i,omitempty"`
+	Next       string `json:"n,omitempty"`
+	Expires    int64  `json:"e"`
+}
+
+// NewOAuthState creates OAuthState:
+// - secret is a key to sign the state cookies
+// - ttl is the time given to the user to complete authorization on the provider's side
+// - secure defines if the cookie should be sent only over HTTPS
+func NewOAuthState(secret string, ttl time.Duration, secure bool) *OAuthState {
+	return &OAuthState{
+		secret: []byte(secret),
+		ttl:    ttl,
+		secure: secure,
+	}
+}
+
+// New generates a state for a new login attempt and stores it in the cookie with the context of the sign in.
+// If pkce is true, a PKCE code verifier is generated too, if nonce is true, a nonce is generated.
+// The returned options should be passed to AuthCodeURL.
+func (s *OAuthState) New(
+	w http.ResponseWriter, pkce, nonce bool, signIn SignIn,
+) (string, []oauth2.AuthCodeOption, error) {
+	data := oauthStateData{Invitation: signIn.Invitation, Next: signIn.Next, Expires: time.Now().Add(s.ttl).Unix()}
+
+	var err error
+	data.State, err = randomString(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var opts []oauth2.AuthCodeOption
+	if pkce {
+		data.Verifier, err = randomString(32)
+		if err != nil {
+			return "", nil, err
+		}
+
+		challenge := sha256.Sum256([]byte(data.Verifier))
+		opts = append(opts,
+			oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+			oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+		)
+	}
+
+	if nonce {
+		data.Nonce, err = randomString(32)
+		if err != nil {
+			return "", nil, err
+		}
+
+		opts = append(opts, oauth2.SetAuthURLParam("nonce", data.Nonce))
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	s.setCookie(w, encoded+"."+s.sign(encoded), int(s.ttl.Seconds()))
+
+	return data.State, opts, nil
+}
+
+// Consume verifies the state returned by the provider against the cookie and deletes the cookie,
+// so the state can't be used twice. The returned options should be passed to Exchange,
+// the context of the sign in is the one given to New with the generated nonce.
+func (s *OAuthState) Consume(
+	w http.ResponseWriter, r *http.Request, state string,
+) (opts []oauth2.AuthCodeOption, signIn SignIn, err error) {
+	cookie, err := r.Cookie(oauthStateCookieName)
+	if err != nil {
+		return nil, SignIn{}, errors.New("state cookie is not found")
+	}
+
+	s.setCookie(w, "", -1)
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(s.sign(parts[0]))) {
+		return nil, SignIn{}, errors.New("state cookie has wrong signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, SignIn{}, err
+	}
+
+	var data oauthStateData
+	if err = json.Unmarshal(payload, &data); err != nil {
+		return nil, SignIn{}, err
+	}
+
+	if time.Now().Unix() > data.Expires {
+		return nil, SignIn{}, errors.New("state is expired")
+	}
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(data.State)) != 1 {
+		return nil, SignIn{}, errors.New("state doesn't match")
+	}
+
+	if data.Verifier != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("code_verifier", data.Verifier))
+	}
+
+	return opts, SignIn{Invitation: data.Invitation, Next: data.Next, Nonce: data.Nonce}, nil
+}
+
+func (s *OAuthState) sign(value string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *OAuthState) setCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    value,
+		Path:     "/oauth",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   s.secure,
+		// Lax allows to send the cookie with the top-level redirect back from the provider
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// randomString returns URL-safe string based on n random bytes
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
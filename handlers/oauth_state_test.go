@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// authCodeURL returns the query of the authorization URL built with the options
+func authCodeURL(t *testing.T, state string, opts []oauth2.AuthCodeOption) url.Values {
+	conf := &oauth2.Config{ClientID: "ui", Endpoint: oauth2.Endpoint{AuthURL: "https://idp.example.com/auth"}}
+	u, err := url.Parse(conf.AuthCodeURL(state, opts...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u.Query()
+}
+
+// callbackRequest returns the callback request with the cookies set by the response
+func callbackRequest(w *httptest.ResponseRecorder) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/oauth/oidc-cb", nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+
+	return r
+}
+
+func TestOAuthStateNonce(t *testing.T) {
+	states := NewOAuthState("secret", time.Minute, true)
+
+	w := httptest.NewRecorder()
+	state, opts, err := states.New(w, false, true, SignIn{Next: "/builds"})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	nonce := authCodeURL(t, state, opts).Get("nonce")
+	if nonce == "" {
+		t.Fatal("authorization URL has no nonce")
+	}
+
+	_, signIn, err := states.Consume(httptest.NewRecorder(), callbackRequest(w), state)
+	if err != nil {
+		t.Fatalf("Consume() = %v", err)
+	}
+	if signIn.Nonce != nonce || signIn.Next != "/builds" {
+		t.Errorf("Consume() = %+v, want nonce %s and next /builds", signIn, nonce)
+	}
+
+	// The providers without ID token don't get the nonce
+	w = httptest.NewRecorder()
+	state, opts, err = states.New(w, false, false, SignIn{})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	if nonce = authCodeURL(t, state, opts).Get("nonce"); nonce != "" {
+		t.Errorf("authorization URL has nonce %s, want none", nonce)
+	}
+}
+
+func TestOAuthStateConsume(t *testing.T) {
+	states := NewOAuthState("secret", time.Minute, true)
+
+	w := httptest.NewRecorder()
+	state, _, err := states.New(w, false, true, SignIn{})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+
+	if _, _, err = states.Consume(httptest.NewRecorder(), callbackRequest(w), "other"); err == nil {
+		t.Error("Consume() with wrong state succeeded, want error")
+	}
+
+	other := NewOAuthState("other secret", time.Minute, true)
+	if _, _, err = other.Consume(httptest.NewRecorder(), callbackRequest(w), state); err == nil {
+		t.Error("Consume() of cookie with wrong signature succeeded, want error")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/oauth/oidc-cb", nil)
+	if _, _, err = states.Consume(httptest.NewRecorder(), r, state); err == nil {
+		t.Error("Consume() without cookie succeeded, want error")
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/takama/router"
+	"golang.org/x/oauth2"
+
+	"github.com/k8s-community/ui/access"
+	"github.com/k8s-community/ui/providers"
+	"github.com/k8s-community/ui/views"
+)
+
+// stubProvider is the provider which authorization is never completed in the tests
+type stubProvider struct {
+	t *testing.T
+}
+
+func (p stubProvider) Name() string   { return "stub" }
+func (p stubProvider) Title() string  { return "Stub" }
+func (p stubProvider) Source() string { return "stub" }
+func (p stubProvider) PKCE() bool     { return false }
+func (p stubProvider) Nonce() bool    { return false }
+
+func (p stubProvider) AuthCodeURL(state string, opts ...oauth2.AuthCodeOption) string {
+	return "https://idp.example.com/auth?state=" + state
+}
+
+func (p stubProvider) Identity(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (*providers.Identity, error) {
+	p.t.Errorf("Identity() is called with code %q", code)
+	return nil, context.Canceled
+}
+
+func TestOAuthCallbackError(t *testing.T) {
+	pages, err := views.New("../templates", logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := logrus.New()
+	states := NewOAuthState("secret", time.Minute, false)
+	h := NewOAuth(log, pages, NewErrorPages(log, pages), nil, nil, states, access.Rules{}, false, stubProvider{t})
+
+	r := router.New()
+	r.GET("/oauth/:provider", h.Handle)
+
+	// The state of a sign in started in the browser
+	login := httptest.NewRecorder()
+	r.ServeHTTP(login, httptest.NewRequest(http.MethodGet, "/oauth/stub", nil))
+	location, err := login.Result().Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := location.Query().Get("state")
+
+	const description = "Your+account+is+locked,+call+%2B1-555-0100"
+	tests := []struct {
+		name    string
+		query   string
+		cookie  bool
+		status  int
+		message string
+	}{
+		{
+			name:    "crafted link",
+			query:   "error=server_error&error_description=" + description,
+			status:  http.StatusBadRequest,
+			message: "Your sign in attempt has expired",
+		},
+		{
+			name:    "denied",
+			query:   "state=" + state + "&error=access_denied&error_description=" + description,
+			cookie:  true,
+			status:  http.StatusForbidden,
+			message: "The sign in was cancelled",
+		},
+		{
+			name:    "failed",
+			query:   "state=" + state + "&error=server_error&error_description=" + description,
+			cookie:  true,
+			status:  http.StatusBadRequest,
+			message: "The sign in has failed",
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/oauth/stub-cb?"+test.query, nil)
+		if test.cookie {
+			for _, cookie := range login.Result().Cookies() {
+				req.AddCookie(cookie)
+			}
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.name, w.Code, test.status)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, test.message) {
+			t.Errorf("%s: page doesn't contain %q", test.name, test.message)
+		}
+		if strings.Contains(body, "locked") {
+			t.Errorf("%s: page contains the error description", test.name)
+		}
+	}
+}
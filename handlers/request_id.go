@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+)
+
+// RequestIDHeader is the header with the ID of the request, it's set by Ingress or generated
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits the IDs taken from the clients, so they can't forge the log lines
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type requestIDKey struct{}
+
+// RequestID is a middleware which assigns the ID to the request, the ID is returned in X-Request-ID header
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			var err error
+			if id, err = randomString(12); err != nil {
+				id = "unknown"
+			}
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// requestID returns the ID assigned to the request by RequestID middleware
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/icza/session"
+	"github.com/takama/router"
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/models"
+	"github.com/k8s-community/ui/tokens"
+	"github.com/k8s-community/ui/views"
+)
+
+// tokensPath is the path of the settings page with the personal access tokens
+const tokensPath = "/settings/tokens"
+
+// Tokens is a handler set of the settings page to manage the personal access tokens
+type Tokens struct {
+	db         *reform.DB
+	log        logrus.FieldLogger
+	pages      *views.Registry
+	errorPages *ErrorPages
+}
+
+// scopeDescriptions are shown on the settings page
+var scopeDescriptions = map[string]string{
+	tokens.ScopeCredentials: "Read your Kubernetes credentials",
+	tokens.ScopeBuilds:      "Read your builds and their logs",
+}
+
+// scopeOption is the scope offered for a new token
+type scopeOption struct {
+	Name        string
+	Description string
+}
+
+// expirationOption is the lifetime offered for a new token
+type expirationOption struct {
+	Value string // the value of the form
+	Title string
+	TTL   time.Duration // zero if the token doesn't expire
+}
+
+// expirations are offered on the settings page, the first one is the default
+var expirations = []expirationOption{
+	{Value: "30d", Title: "30 days", TTL: 30 * 24 * time.Hour},
+	{Value: "90d", Title: "90 days", TTL: 90 * 24 * time.Hour},
+	{Value: "1y", Title: "1 year", TTL: 365 * 24 * time.Hour},
+	{Value: "never", Title: "No expiration"},
+}
+
+// tokensPage is the data of the settings page
+type tokensPage struct {
+	Login  string
+	Tokens []*models.AccessToken
+	Scopes []scopeOption
+	CSRF   string // the forms are accepted only with this value
+
+	Expirations []expirationOption
+	Expiration  string // the selected value of the expiration
+
+	NewToken     string // the created token, it's shown only once
+	NewTokenName string
+	Error        string // the token couldn't be created
+}
+
+// NewTokens creates Tokens handler set
+func NewTokens(db *reform.DB, log logrus.FieldLogger, pages *views.Registry, errorPages *ErrorPages) *Tokens {
+	return &Tokens{db: db, log: log, pages: pages, errorPages: errorPages}
+}
+
+// Page is a handler of the page with the tokens of the signed in user: GET /settings/tokens
+func (h *Tokens) Page(c *router.Control) {
+	user, sessionData := h.user(c)
+	if user == nil {
+		return
+	}
+
+	h.render(c, http.StatusOK, user, &tokensPage{CSRF: csrfToken(sessionData), Expiration: expirations[0].Value})
+}
+
+// Create is a handler to create a new token, the page is shown with the new token: POST /settings/tokens
+func (h *Tokens) Create(c *router.Control) {
+	user, sessionData := h.user(c)
+	if user == nil || !h.checkCSRF(c, sessionData) {
+		return
+	}
+
+	data := &tokensPage{
+		CSRF:         csrfToken(sessionData),
+		NewTokenName: c.Request.PostFormValue("name"),
+		Expiration:   c.Request.PostFormValue("expiration"),
+	}
+
+	var scopes []string
+	for _, scope := range c.Request.PostForm["scope"] {
+		if tokens.Known(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	expiration, ok := findExpiration(data.Expiration)
+	if !ok {
+		data.Error = "Choose the expiration of the token"
+		data.Expiration = expirations[0].Value
+		h.render(c, http.StatusBadRequest, user, data)
+		return
+	}
+
+	token, _, err := tokens.Create(h.db.Querier, user.ID, data.NewTokenName, scopes, expiration.TTL)
+	if err == tokens.ErrInvalidName {
+		data.Error = "The name should be from 1 to 128 characters long"
+		h.render(c, http.StatusBadRequest, user, data)
+		return
+	}
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't create access token of user %s: %v", user.Name, err))
+		return
+	}
+
+	h.log.WithField("user", user.Name).Infof("Access token %s was created", data.NewTokenName)
+	data.NewToken = token
+	h.render(c, http.StatusOK, user, data)
+}
+
+// Revoke is a handler to revoke the token: POST /settings/tokens/:id/revoke
+func (h *Tokens) Revoke(c *router.Control) {
+	user, sessionData := h.user(c)
+	if user == nil || !h.checkCSRF(c, sessionData) {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Get(":id"), 10, 64)
+	if err != nil {
+		h.errorPages.Render(c, NewError(http.StatusNotFound, "", err))
+		return
+	}
+
+	err = tokens.Revoke(h.db, user.ID, id)
+	if err == tokens.ErrNotFound {
+		h.errorPages.Render(c, NewError(http.StatusNotFound, "The token is not found", nil))
+		return
+	}
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't revoke access token %d of user %s: %v", id, user.Name, err))
+		return
+	}
+
+	h.log.WithField("user", user.Name).Infof("Access token %d was revoked", id)
+	http.Redirect(c.Writer, c.Request, tokensPath, http.StatusSeeOther)
+}
+
+// findExpiration returns the offered expiration of the value of the form
+func findExpiration(value string) (expirationOption, bool) {
+	for _, expiration := range expirations {
+		if expiration.Value == value {
+			return expiration, true
+		}
+	}
+
+	return expirationOption{}, false
+}
+
+// user returns the signed in user, the guests are redirected to the home page and nil is returned
+func (h *Tokens) user(c *router.Control) (*models.User, session.Session) {
+	sessionData := session.Get(c.Request)
+	if sessionData == nil {
+		http.Redirect(c.Writer, c.Request, "/", http.StatusFound)
+		return nil, nil
+	}
+
+	login := sessionData.CAttr("Login").(string)
+	user, err := findUser(h.db, sessionData.CAttr("Source").(string), login)
+	if err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't get user %s from DB: %v", login, err))
+		return nil, nil
+	}
+
+	return user, sessionData
+}
+
+// checkCSRF tells if the form is sent from the page of the session, 403 is written otherwise
+func (h *Tokens) checkCSRF(c *router.Control, sessionData session.Session) bool {
+	csrf := c.Request.PostFormValue("csrf")
+	if !validCSRF(csrf, sessionData) {
+		h.errorPages.Render(c, NewError(
+			http.StatusForbidden, "The form has expired, please try again", fmt.Errorf("wrong CSRF token %q", csrf),
+		))
+		return false
+	}
+
+	return true
+}
+
+// render writes the settings page with the tokens of the user
+func (h *Tokens) render(c *router.Control, status int, user *models.User, data *tokensPage) {
+	var err error
+	if data.Tokens, err = tokens.List(h.db, user.ID); err != nil {
+		h.errorPages.Render(c, fmt.Errorf("couldn't get access tokens of user %s: %v", user.Name, err))
+		return
+	}
+	data.Login = user.Name
+	data.Scopes = make([]scopeOption, 0, len(tokens.Scopes))
+	for _, scope := range tokens.Scopes {
+		data.Scopes = append(data.Scopes, scopeOption{Name: scope, Description: scopeDescriptions[scope]})
+	}
+	data.Expirations = expirations
+
+	lang := language(c, h.pages)
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Cache-Control", "no-store")
+	c.Writer.WriteHeader(status)
+	if err = h.pages.Render(c.Writer, lang, "tokens", data); err != nil {
+		h.log.WithField("user", user.Name).Errorf("Couldn't render access tokens page: %+v", err)
+	}
+}
+
+// csrfToken returns the value the forms of the session are accepted with.
+// It's derived from the session ID which is kept in HttpOnly cookie, so the other sites can't get it.
+func csrfToken(sessionData session.Session) string {
+	sum := sha256.Sum256([]byte("csrf:" + sessionData.ID()))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// validCSRF tells if the value sent with the form is the one of the session
+func validCSRF(csrf string, sessionData session.Session) bool {
+	return subtle.ConstantTimeCompare([]byte(csrf), []byte(csrfToken(sessionData))) == 1
+}
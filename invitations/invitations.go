@@ -0,0 +1,161 @@
+// Package invitations manages the codes to enroll in the workshops
+package invitations
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/models"
+)
+
+// ErrInvalid is returned for the codes which don't exist, are used up or expired
+var ErrInvalid = errors.New("invitation code is invalid, expired or used up")
+
+// codeAlphabet has no similar looking characters, so the codes are easy to type
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Options defines the code to create
+type Options struct {
+	// Code is generated if it's empty
+	Code     string
+	Workshop string
+	MaxUses  int
+
+	// TTL is the time the code is valid, the code doesn't expire if it's zero
+	TTL time.Duration
+}
+
+// Create creates a new invitation code
+func Create(db *reform.DB, options Options) (*models.Invitation, error) {
+	code := normalize(options.Code)
+	if code == "" {
+		var err error
+		if code, err = generate(10); err != nil {
+			return nil, err
+		}
+	}
+
+	invitation := &models.Invitation{
+		Code:    code,
+		MaxUses: options.MaxUses,
+	}
+	if options.Workshop != "" {
+		invitation.Workshop = &options.Workshop
+	}
+	if options.TTL > 0 {
+		expiresAt := time.Now().UTC().Truncate(time.Second).Add(options.TTL)
+		invitation.ExpiresAt = &expiresAt
+	}
+
+	if err := db.Insert(invitation); err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// Find returns the usable invitation specified by the code or ErrInvalid
+func Find(db *reform.DB, code string) (*models.Invitation, error) {
+	st, err := db.FindOneFrom(models.InvitationTable, "code", normalize(code))
+	if err == reform.ErrNoRows {
+		return nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := st.(*models.Invitation)
+	if !invitation.Usable(time.Now().UTC()) {
+		return nil, ErrInvalid
+	}
+
+	return invitation, nil
+}
+
+// Redeem enrolls the user specified by source and login with the code.
+// The code is used once per user, so signing in again with the same code doesn't use it up.
+func Redeem(db *reform.DB, code, source, login string) error {
+	return db.InTransaction(func(tx *reform.TX) error {
+		// Lock the code, so the concurrent redemptions don't exceed the limit
+		st, err := tx.SelectOneFrom(models.InvitationTable, "WHERE code = $1 FOR UPDATE", normalize(code))
+		if err == reform.ErrNoRows {
+			return ErrInvalid
+		}
+		if err != nil {
+			return err
+		}
+		invitation := st.(*models.Invitation)
+
+		_, err = tx.SelectOneFrom(
+			models.RedemptionTable, "WHERE code_id = $1 AND source = $2 AND name = $3", invitation.ID, source, login,
+		)
+		if err == nil {
+			return nil
+		}
+		if err != reform.ErrNoRows {
+			return err
+		}
+
+		if !invitation.Usable(time.Now().UTC()) {
+			return ErrInvalid
+		}
+
+		invitation.Uses++
+		if err = tx.UpdateColumns(invitation, "uses"); err != nil {
+			return err
+		}
+
+		return tx.Insert(&models.Redemption{CodeID: invitation.ID, Source: source, Name: login})
+	})
+}
+
+// Enrolled tells if the user has redeemed a code already
+func Enrolled(db *reform.DB, source, login string) (bool, error) {
+	_, err := db.SelectOneFrom(models.RedemptionTable, "WHERE source = $1 AND name = $2 LIMIT 1", source, login)
+	if err == reform.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// List returns all the codes, the newest first
+func List(db *reform.DB) ([]*models.Invitation, error) {
+	structs, err := db.SelectAllFrom(models.InvitationTable, "ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := make([]*models.Invitation, 0, len(structs))
+	for _, st := range structs {
+		invitations = append(invitations, st.(*models.Invitation))
+	}
+
+	return invitations, nil
+}
+
+// normalize makes the codes case-insensitive
+func normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// generate returns a random code of n characters
+func generate(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+
+	return string(b), nil
+}
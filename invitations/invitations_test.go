@@ -0,0 +1,126 @@
+package invitations
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k8s-community/ui/db/dbtest"
+	"github.com/k8s-community/ui/models"
+)
+
+func TestUsable(t *testing.T) {
+	now := time.Now().UTC()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		invitation models.Invitation
+		usable     bool
+	}{
+		{invitation: models.Invitation{MaxUses: 1}, usable: true},
+		{invitation: models.Invitation{MaxUses: 2, Uses: 1, ExpiresAt: &future}, usable: true},
+		{invitation: models.Invitation{MaxUses: 1, Uses: 1}},
+		{invitation: models.Invitation{MaxUses: 0}},
+		{invitation: models.Invitation{MaxUses: 1, ExpiresAt: &past}},
+		{invitation: models.Invitation{MaxUses: 1, ExpiresAt: &now}},
+	}
+
+	for i, test := range tests {
+		if usable := test.invitation.Usable(now); usable != test.usable {
+			t.Errorf("%d: Usable() = %v, want %v", i, usable, test.usable)
+		}
+	}
+}
+
+func TestRedeemLimit(t *testing.T) {
+	db := dbtest.DB(t)
+
+	invitation, err := Create(db, Options{MaxUses: 2})
+	if err != nil {
+		t.Fatalf("Create() = %v", err)
+	}
+
+	alice, bob, carol := dbtest.Login("alice"), dbtest.Login("bob"), dbtest.Login("carol")
+	steps := []struct {
+		login string
+		err   error
+	}{
+		{login: alice},
+		{login: alice}, // signing in again doesn't use the code up
+		{login: bob},
+		{login: carol, err: ErrInvalid},
+		{login: bob}, // the enrolled users still sign in with the used up code
+	}
+	for i, step := range steps {
+		if err = Redeem(db, invitation.Code, "github", step.login); err != step.err {
+			t.Errorf("%d: Redeem(%s) = %v, want %v", i, step.login, err, step.err)
+		}
+	}
+
+	if _, err = Find(db, invitation.Code); err != ErrInvalid {
+		t.Errorf("Find() of used up code = %v, want %v", err, ErrInvalid)
+	}
+	if enrolled, err := Enrolled(db, "gitlab", alice); err != nil || enrolled {
+		t.Errorf("Enrolled() of other source = %v, %v, want false", enrolled, err)
+	}
+}
+
+func TestRedeemInvalid(t *testing.T) {
+	db := dbtest.DB(t)
+
+	if err := Redeem(db, "NO-SUCH-CODE-"+dbtest.Login("code"), "github", dbtest.Login("alice")); err != ErrInvalid {
+		t.Errorf("Redeem() of unknown code = %v, want %v", err, ErrInvalid)
+	}
+
+	invitation, err := Create(db, Options{MaxUses: 10, TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("Create() = %v", err)
+	}
+	expired := time.Now().UTC().Add(-time.Minute)
+	invitation.ExpiresAt = &expired
+	if err = db.UpdateColumns(invitation, "expires_at"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Redeem(db, invitation.Code, "github", dbtest.Login("alice")); err != ErrInvalid {
+		t.Errorf("Redeem() of expired code = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestRedeemConcurrently(t *testing.T) {
+	db := dbtest.DB(t)
+
+	const maxUses = 3
+	invitation, err := Create(db, Options{MaxUses: maxUses})
+	if err != nil {
+		t.Fatalf("Create() = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- Redeem(db, invitation.Code, "github", dbtest.Login(fmt.Sprintf("user%d", i)))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	redeemed := 0
+	for err := range errs {
+		switch err {
+		case nil:
+			redeemed++
+		case ErrInvalid:
+		default:
+			t.Errorf("Redeem() = %v", err)
+		}
+	}
+	if redeemed != maxUses {
+		t.Errorf("code was redeemed %d times, want %d", redeemed, maxUses)
+	}
+}
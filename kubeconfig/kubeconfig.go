@@ -0,0 +1,178 @@
+package kubeconfig
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is a minimal representation of kubeconfig file (apiVersion v1)
+type Config struct {
+	APIVersion     string         `yaml:"apiVersion"`
+	Kind           string         `yaml:"kind"`
+	Clusters       []NamedCluster `yaml:"clusters"`
+	Users          []NamedUser    `yaml:"users"`
+	Contexts       []NamedContext `yaml:"contexts"`
+	CurrentContext string         `yaml:"current-context"`
+}
+
+// NamedCluster relates nicknames to cluster information
+type NamedCluster struct {
+	Name    string  `yaml:"name"`
+	Cluster Cluster `yaml:"cluster"`
+}
+
+// Cluster contains information about how to communicate with a Kubernetes cluster
+type Cluster struct {
+	Server string `yaml:"server"`
+	// CertificateAuthorityData contains PEM-encoded certificate authority certificates (base64)
+	CertificateAuthorityData string `yaml:"certificate-authority-data,omitempty"`
+}
+
+// NamedUser relates nicknames to user credentials
+type NamedUser struct {
+	Name string   `yaml:"name"`
+	User AuthInfo `yaml:"user"`
+}
+
+// AuthInfo contains information that describes identity information
+type AuthInfo struct {
+	Token string `yaml:"token,omitempty"`
+}
+
+// NamedContext relates nicknames to context information
+type NamedContext struct {
+	Name    string  `yaml:"name"`
+	Context Context `yaml:"context"`
+}
+
+// Context is a tuple of references to a cluster, a user and a namespace
+type Context struct {
+	Cluster   string `yaml:"cluster"`
+	User      string `yaml:"user"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// New creates a kubeconfig with the only cluster, user and context:
+//   - cluster and server are the name and the API server URL of Kubernetes cluster
+//   - login is the user's Kubernetes name (see models.User.KubernetesName: the login of github.com users,
+//     the source, the login and their hash for the others), it's used as user and context name, the namespace
+//     is the lower-cased name (user-manager creates namespaces this way)
+//   - token and caCert are user's credentials provided by user-manager
+func New(cluster, server, login, token, caCert string) *Config {
+	return &Config{
+		APIVersion: "v1",
+		Kind:       "Config",
+		Clusters: []NamedCluster{
+			{
+				Name: cluster,
+				Cluster: Cluster{
+					Server:                   server,
+					CertificateAuthorityData: base64.StdEncoding.EncodeToString([]byte(caCert)),
+				},
+			},
+		},
+		Users: []NamedUser{
+			{
+				Name: login,
+				User: AuthInfo{Token: token},
+			},
+		},
+		Contexts: []NamedContext{
+			{
+				Name: login,
+				Context: Context{
+					Cluster:   cluster,
+					User:      login,
+					Namespace: strings.ToLower(login),
+				},
+			},
+		},
+		CurrentContext: login,
+	}
+}
+
+// Marshal returns YAML representation of kubeconfig
+func (c *Config) Marshal() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+// Merge adds the clusters, the users and the contexts of the config to the existing kubeconfig file
+// and makes the current context of the config the current one. The entries with the same names are replaced,
+// the other entries and the settings unknown to Config are kept as is.
+func Merge(existing []byte, config *Config) ([]byte, error) {
+	var merged yaml.MapSlice
+	if err := yaml.Unmarshal(existing, &merged); err != nil {
+		return nil, err
+	}
+
+	content, err := config.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	var added yaml.MapSlice
+	if err = yaml.Unmarshal(content, &added); err != nil {
+		return nil, err
+	}
+
+	for _, item := range added {
+		switch item.Key {
+		case "clusters", "users", "contexts":
+			current, _ := lookup(merged, item.Key).([]interface{})
+			merged = set(merged, item.Key, mergeNamed(current, item.Value.([]interface{})))
+		case "current-context":
+			merged = set(merged, item.Key, item.Value)
+		default:
+			if lookup(merged, item.Key) == nil {
+				merged = set(merged, item.Key, item.Value)
+			}
+		}
+	}
+
+	return yaml.Marshal(merged)
+}
+
+// mergeNamed replaces the entries of the list with the added ones of the same names, the rest are appended
+func mergeNamed(list, added []interface{}) []interface{} {
+	for _, entry := range added {
+		name := lookup(entry.(yaml.MapSlice), "name")
+
+		replaced := false
+		for i, existing := range list {
+			if existingEntry, ok := existing.(yaml.MapSlice); ok && lookup(existingEntry, "name") == name {
+				list[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
+
+// lookup returns the value of the key, nil if there is no such a key
+func lookup(m yaml.MapSlice, key interface{}) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+
+	return nil
+}
+
+// set sets the value of the key keeping the order of the keys, the new key is appended
+func set(m yaml.MapSlice, key, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
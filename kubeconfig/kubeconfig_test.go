@@ -0,0 +1,113 @@
+package kubeconfig
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestMerge(t *testing.T) {
+	existing := `apiVersion: v1
+kind: Config
+preferences:
+  colors: true
+clusters:
+- name: minikube
+  cluster:
+    server: https://192.168.99.100:8443
+- name: workshop
+  cluster:
+    server: https://old.example.com
+users:
+- name: minikube
+  user:
+    client-certificate: /home/alice/.minikube/client.crt
+- name: alice
+  user:
+    token: old
+contexts:
+- name: minikube
+  context:
+    cluster: minikube
+    user: minikube
+- name: alice
+  context:
+    cluster: workshop
+    user: alice
+    namespace: old
+current-context: minikube
+`
+
+	merged, err := Merge([]byte(existing), New("workshop", "https://k8s.example.com", "alice", "new", "ca"))
+	if err != nil {
+		t.Fatalf("Merge() = %v", err)
+	}
+
+	var got map[string]interface{}
+	if err = yaml.Unmarshal(merged, &got); err != nil {
+		t.Fatal(err)
+	}
+	var want map[string]interface{}
+	err = yaml.Unmarshal([]byte(`apiVersion: v1
+kind: Config
+preferences:
+  colors: true
+clusters:
+- name: minikube
+  cluster:
+    server: https://192.168.99.100:8443
+- name: workshop
+  cluster:
+    server: https://k8s.example.com
+    certificate-authority-data: Y2E=
+users:
+- name: minikube
+  user:
+    client-certificate: /home/alice/.minikube/client.crt
+- name: alice
+  user:
+    token: new
+contexts:
+- name: minikube
+  context:
+    cluster: minikube
+    user: minikube
+- name: alice
+  context:
+    cluster: workshop
+    user: alice
+    namespace: alice
+current-context: alice
+`), &want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %s", merged)
+	}
+}
+
+func TestMergeEmpty(t *testing.T) {
+	config := New("workshop", "https://k8s.example.com", "gitlab--alice-4d2a29dff9", "token", "ca")
+	want, err := config.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file doesn't exist yet
+	merged, err := Merge(nil, config)
+	if err != nil {
+		t.Fatalf("Merge() = %v", err)
+	}
+	if string(merged) != string(want) {
+		t.Errorf("Merge() into empty file = %s, want %s", merged, want)
+	}
+}
+
+func TestMergeInvalid(t *testing.T) {
+	if _, err := Merge([]byte("clusters: [}"), New("workshop", "https://k8s.example.com", "alice", "t", "ca")); err == nil {
+		t.Error("Merge() of invalid file doesn't return error")
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+//go:generate reform
+
+// AccessToken is a personal access token of the API client, only the hash of the token is kept
+//
+//reform:access_tokens
+type AccessToken struct {
+	ID         int64      `reform:"id,pk"`
+	UserID     int64      `reform:"user_id"`
+	Name       string     `reform:"name"`
+	TokenHash  string     `reform:"token_hash"`
+	Scopes     string     `reform:"scopes"` // comma-separated
+	LastUsedAt *time.Time `reform:"last_used_at"`
+	CreatedAt  time.Time  `reform:"created_at"`
+	ExpiresAt  *time.Time `reform:"expires_at"` // nil if the token doesn't expire
+}
+
+// BeforeInsert set CreatedAt.
+func (t *AccessToken) BeforeInsert() error {
+	t.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	return nil
+}
+
+// Expired tells if the token has expired by the time
+func (t *AccessToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// ScopeList returns the scopes of the token
+func (t *AccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+
+	return strings.Split(t.Scopes, ",")
+}
+
+// HasScope tells if the token is allowed to be used for the scope
+func (t *AccessToken) HasScope(scope string) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
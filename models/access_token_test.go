@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccessTokenHasScope(t *testing.T) {
+	tests := []struct {
+		scopes string
+		scope  string
+		has    bool
+	}{
+		{scopes: "credentials:read,builds:read", scope: "builds:read", has: true},
+		{scopes: "builds:read", scope: "credentials:read", has: false},
+		{scopes: "", scope: "credentials:read", has: false},
+		{scopes: "credentials:read", scope: "credentials", has: false},
+	}
+
+	for _, test := range tests {
+		token := &AccessToken{Scopes: test.scopes}
+		if has := token.HasScope(test.scope); has != test.has {
+			t.Errorf("HasScope(%s) of token with %q scopes = %v, want %v", test.scope, test.scopes, has, test.has)
+		}
+	}
+}
+
+func TestAccessTokenExpired(t *testing.T) {
+	now := time.Now().UTC()
+	past, future := now.Add(-time.Second), now.Add(time.Second)
+
+	if (&AccessToken{}).Expired(now) {
+		t.Error("token without expiration has expired")
+	}
+	if (&AccessToken{ExpiresAt: &future}).Expired(now) {
+		t.Error("token has expired before its expiration")
+	}
+	if !(&AccessToken{ExpiresAt: &now}).Expired(now) || !(&AccessToken{ExpiresAt: &past}).Expired(now) {
+		t.Error("token hasn't expired after its expiration")
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// Possible states of device authorizations
+const (
+	DevicePending  = "pending"
+	DeviceApproved = "approved"
+	DeviceDenied   = "denied"
+)
+
+//go:generate reform
+
+// DeviceAuthorization is a sign in of the device (the CLI) waiting for the approval of the user
+//
+//reform:device_authorizations
+type DeviceAuthorization struct {
+	ID             int64      `reform:"id,pk"`
+	DeviceCodeHash string     `reform:"device_code_hash"`
+	UserCode       string     `reform:"user_code"`
+	UserID         *int64     `reform:"user_id"` // the user who approved or denied the sign in
+	State          string     `reform:"state"`
+	PollInterval   int        `reform:"poll_interval"` // in seconds
+	LastPolledAt   *time.Time `reform:"last_polled_at"`
+	ExpiresAt      time.Time  `reform:"expires_at"`
+	CreatedAt      time.Time  `reform:"created_at"`
+	ClientIP       string     `reform:"client_ip"` // the address the device code was requested from
+}
+
+// BeforeInsert set CreatedAt.
+func (d *DeviceAuthorization) BeforeInsert() error {
+	d.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	return nil
+}
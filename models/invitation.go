@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+//go:generate reform
+
+// Invitation is a code to enroll in the workshop
+//
+//reform:invitation_codes
+type Invitation struct {
+	ID        int64      `reform:"id,pk"`
+	Code      string     `reform:"code"`
+	Workshop  *string    `reform:"workshop"`
+	MaxUses   int        `reform:"max_uses"`
+	Uses      int        `reform:"uses"`
+	ExpiresAt *time.Time `reform:"expires_at"`
+	CreatedAt time.Time  `reform:"created_at"`
+}
+
+// BeforeInsert set CreatedAt.
+func (i *Invitation) BeforeInsert() error {
+	i.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	return nil
+}
+
+// Usable tells if the code isn't used up and isn't expired
+func (i *Invitation) Usable(now time.Time) bool {
+	return i.Uses < i.MaxUses && (i.ExpiresAt == nil || now.Before(*i.ExpiresAt))
+}
+
+// Redemption records the user enrolled with the invitation code
+//
+//reform:invitation_redemptions
+type Redemption struct {
+	ID        int64     `reform:"id,pk"`
+	CodeID    int64     `reform:"code_id"`
+	Source    string    `reform:"source"`
+	Name      string    `reform:"name"`
+	CreatedAt time.Time `reform:"created_at"`
+}
+
+// BeforeInsert set CreatedAt.
+func (r *Redemption) BeforeInsert() error {
+	r.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	return nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// Possible states of provisioning jobs
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+//go:generate reform
+
+// ProvisioningJob is a job to create the user's environment in Kubernetes via user-manager
+//
+//reform:provisioning_jobs
+type ProvisioningJob struct {
+	ID        int64     `reform:"id,pk"`
+	UserID    int64     `reform:"user_id"`
+	State     string    `reform:"state"`
+	Attempts  int       `reform:"attempts"`
+	LastError *string   `reform:"last_error"`
+	RunAt     time.Time `reform:"run_at"`
+	CreatedAt time.Time `reform:"created_at"`
+	UpdatedAt time.Time `reform:"updated_at"`
+}
+
+// BeforeInsert set CreatedAt and UpdatedAt.
+func (j *ProvisioningJob) BeforeInsert() error {
+	j.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	j.UpdatedAt = j.CreatedAt
+	return nil
+}
+
+// BeforeUpdate set UpdatedAt.
+func (j *ProvisioningJob) BeforeUpdate() error {
+	j.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	return nil
+}
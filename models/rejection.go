@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+//go:generate reform
+
+// Rejection is a sign in rejected by the access rules
+//
+//reform:sign_in_rejections
+type Rejection struct {
+	ID        int64     `reform:"id,pk"`
+	Source    string    `reform:"source"`
+	Name      string    `reform:"name"`
+	Reason    string    `reform:"reason"`
+	UserAgent *string   `reform:"user_agent"`
+	IP        *string   `reform:"ip"`
+	CreatedAt time.Time `reform:"created_at"`
+}
+
+// BeforeInsert set CreatedAt.
+func (r *Rejection) BeforeInsert() error {
+	r.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	return nil
+}
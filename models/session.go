@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+//go:generate reform
+
+//reform:sessions
+type Session struct {
+	ID           int64      `reform:"id,pk"`
+	SessionID    string     `reform:"session_id"`
+	UserID       int64      `reform:"user_id"`
+	Data         string     `reform:"data"`
+	UserAgent    *string    `reform:"user_agent"`
+	IP           *string    `reform:"ip"`
+	CreatedAt    time.Time  `reform:"created_at"`
+	LastAccessAt time.Time  `reform:"last_access_at"`
+	ExpiresAt    *time.Time `reform:"expires_at"`
+}
+
+// BeforeInsert set CreatedAt and LastAccessAt.
+func (s *Session) BeforeInsert() error {
+	s.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	s.LastAccessAt = s.CreatedAt
+	return nil
+}
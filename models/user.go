@@ -1,27 +1,32 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 	"time"
 )
 
 // Possible types of sources
 const (
 	SourceGitHub = "github"
+	SourceGitLab = "gitlab"
+
+	// SourceOIDC is followed by the issuer, e.g. oidc:keycloak.example.com/auth/realms/workshop
+	SourceOIDC = "oidc"
 )
 
 //go:generate reform
 
 //reform:users
 type User struct {
-	ID          int64     `reform:"id,pk"`
-	Name        string    `reform:"name"`
-	Source      string    `reform:"source"`
-	SessionID   *string   `reform:"session_id"`
-	SessionData *string   `reform:"session_data"`
-	Token       *string   `reform:"token"`
-	Cert        *string   `reform:"ca_crt"`
-	CreatedAt   time.Time `reform:"created_at"`
-	UpdatedAt   time.Time `reform:"updated_at"`
+	ID        int64     `reform:"id,pk"`
+	Name      string    `reform:"name"`
+	Source    string    `reform:"source"`
+	Token     *string   `reform:"token"`
+	Cert      *string   `reform:"ca_crt"`
+	CreatedAt time.Time `reform:"created_at"`
+	UpdatedAt time.Time `reform:"updated_at"`
 }
 
 // BeforeInsert set CreatedAt and UpdatedAt.
@@ -36,3 +41,38 @@ func (u *User) BeforeUpdate() error {
 	u.UpdatedAt = time.Now().UTC().Truncate(time.Second)
 	return nil
 }
+
+// kubernetesNameHash is the number of hex digits of the hash added to the names of the users of other sources
+const kubernetesNameHash = 10
+
+// KubernetesName returns the name of the user in Kubernetes, the user's namespace is named after it.
+// The users of github.com keep their logins. The names of the users of the other sources are
+// <source>--<login>-<hash>: GitHub logins can't contain "--", so they don't collide with github.com users,
+// and the hash of the source and the login keeps the names unique when they are changed
+// to fit DNS label (lower-cased, the other characters replaced by '-', cut to 63 characters).
+func (u *User) KubernetesName() string {
+	if u.Source == SourceGitHub {
+		return u.Name
+	}
+
+	sum := sha256.Sum256([]byte(u.Source + "/" + u.Name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:kubernetesNameHash]
+
+	name := dnsLabel(u.Source) + "--" + dnsLabel(u.Name)
+	if len(name) > 63-len(suffix) {
+		name = name[:63-len(suffix)]
+	}
+
+	return name + suffix
+}
+
+// dnsLabel replaces the characters which aren't allowed in DNS label
+// (lowercase alphanumeric characters or '-') by '-'
+func dnsLabel(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(s))
+}
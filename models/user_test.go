@@ -0,0 +1,45 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// dnsLabelRE matches the names allowed for Kubernetes namespaces
+var dnsLabelRE = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+func TestKubernetesName(t *testing.T) {
+	if name := (&User{Source: SourceGitHub, Name: "alice"}).KubernetesName(); name != "alice" {
+		t.Errorf("KubernetesName() of github.com user = %s, want the login", name)
+	}
+	if name := (&User{Source: SourceGitLab, Name: "alice"}).KubernetesName(); name != "gitlab--alice-4d2a29dff9" {
+		t.Errorf("KubernetesName() of gitlab.com user = %s, want gitlab--alice-4d2a29dff9", name)
+	}
+
+	long := strings.Repeat("a", 100)
+	users := []User{
+		{Source: SourceGitHub, Name: "gitlab-alice"},
+		{Source: SourceGitLab, Name: "alice"},
+		{Source: SourceGitLab, Name: "Alice"},
+		{Source: SourceGitLab, Name: "alice.b"},
+		{Source: SourceGitLab, Name: "alice_b"},
+		{Source: "github:ghe.example.com", Name: "alice"},
+		{Source: "github:ghe-example.com", Name: "alice"},
+		{Source: "oidc:keycloak.example.com/auth/realms/workshop", Name: "alice@example.com"},
+		{Source: SourceGitLab, Name: long + "1"},
+		{Source: SourceGitLab, Name: long + "2"},
+	}
+
+	owners := make(map[string]User)
+	for _, user := range users {
+		name := user.KubernetesName()
+		if len(name) > 63 || !dnsLabelRE.MatchString(strings.ToLower(name)) {
+			t.Errorf("KubernetesName() of %s/%s = %s, want DNS label", user.Source, user.Name, name)
+		}
+		if owner, ok := owners[strings.ToLower(name)]; ok {
+			t.Errorf("%s/%s and %s/%s have the same name %s", user.Source, user.Name, owner.Source, owner.Name, name)
+		}
+		owners[strings.ToLower(name)] = user
+	}
+}
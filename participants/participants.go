@@ -0,0 +1,162 @@
+// Package participants lists the users of the workshop with the state of their environments
+// and implements the actions the instructors take on them
+package participants
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/models"
+)
+
+// Possible orders of the list
+const (
+	SortLogin   = "login"
+	SortSource  = "source"
+	SortCreated = "created" // the newest first
+	SortUpdated = "updated" // the recently updated first
+)
+
+// orders map the orders of the list to SQL, the id keeps the order of the pages stable
+var orders = map[string]string{
+	SortLogin:   "ORDER BY name, source, id",
+	SortSource:  "ORDER BY source, name, id",
+	SortCreated: "ORDER BY created_at DESC, id DESC",
+	SortUpdated: "ORDER BY updated_at DESC, id DESC",
+}
+
+// ErrNotFound is returned if there is no such a user
+var ErrNotFound = errors.New("user not found")
+
+// ListOptions defines the users to list
+type ListOptions struct {
+	// Search is a part of the login or the source, all the users are listed if it's empty
+	Search string
+
+	// Sort is one of Sort* constants, SortLogin is used for the unknown ones
+	Sort string
+
+	// Page starts from 1
+	Page    int
+	PerPage int
+}
+
+// Participant is the user with the provisioning job and the number of the active sessions
+type Participant struct {
+	User     *models.User
+	Job      *models.ProvisioningJob // nil for the users provisioned before the jobs were introduced
+	Sessions int
+}
+
+// List returns a page of the users matching the options and the number of all the matching users
+func List(db *reform.DB, options ListOptions) ([]*Participant, int, error) {
+	where := ""
+	var args []interface{}
+	if options.Search != "" {
+		where = "WHERE name ILIKE $1 OR source ILIKE $1"
+		args = append(args, "%"+escapeLike(options.Search)+"%")
+	}
+
+	var total int
+	err := db.QueryRow("SELECT COUNT(*) FROM "+db.QualifiedView(models.UserTable)+" "+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := orders[options.Sort]
+	if !ok {
+		order = orders[SortLogin]
+	}
+
+	tail := fmt.Sprintf(
+		"%s %s LIMIT %s OFFSET %s", where, order, db.Placeholder(len(args)+1), db.Placeholder(len(args)+2),
+	)
+	args = append(args, options.PerPage, (options.Page-1)*options.PerPage)
+	structs, err := db.SelectAllFrom(models.UserTable, tail, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(structs) == 0 {
+		return nil, total, nil
+	}
+
+	list := make([]*Participant, 0, len(structs))
+	byID := make(map[int64]*Participant, len(structs))
+	ids := make([]interface{}, 0, len(structs))
+	for _, st := range structs {
+		user := st.(*models.User)
+		p := &Participant{User: user}
+		list = append(list, p)
+		byID[user.ID] = p
+		ids = append(ids, user.ID)
+	}
+
+	structs, err = db.FindAllFrom(models.ProvisioningJobTable, "user_id", ids...)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, st := range structs {
+		job := st.(*models.ProvisioningJob)
+		byID[job.UserID].Job = job
+	}
+
+	rows, err := db.Query(
+		"SELECT user_id, COUNT(*) FROM "+db.QualifiedView(models.SessionTable)+
+			" WHERE user_id IN ("+strings.Join(db.Placeholders(1, len(ids)), ", ")+") GROUP BY user_id",
+		ids...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var sessions int
+		if err = rows.Scan(&id, &sessions); err != nil {
+			return nil, 0, err
+		}
+		byID[id].Sessions = sessions
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return list, total, nil
+}
+
+// Find returns the user specified by the id
+func Find(db *reform.DB, id int64) (*models.User, error) {
+	st, err := db.FindByPrimaryKeyFrom(models.UserTable, id)
+	if err == reform.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return st.(*models.User), nil
+}
+
+// ClearCredentials forgets the Kubernetes token and certificate of the user,
+// they are received from user-manager again with the next sync
+func ClearCredentials(db *reform.DB, user *models.User) error {
+	user.Token = nil
+	user.Cert = nil
+
+	return db.UpdateColumns(user, "token", "ca_crt", "updated_at")
+}
+
+// SignOut deletes all the sessions of the user, the number of the deleted sessions is returned.
+// The personal access tokens are kept, the user revokes them on the settings page.
+func SignOut(db *reform.DB, user *models.User) (uint, error) {
+	return db.DeleteFrom(models.SessionTable, "WHERE user_id = $1", user.ID)
+}
+
+// escapeLike escapes the wildcards of LIKE pattern, so the search is for the literal text
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
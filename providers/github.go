@@ -0,0 +1,188 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	ghClient "github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+	ghOAuth "golang.org/x/oauth2/github"
+
+	"github.com/k8s-community/ui/models"
+)
+
+// GitHubOptions contains settings of GitHub provider
+type GitHubOptions struct {
+	// ClientID and ClientSecret are the parameters from github.com/settings/developers
+	ClientID     string
+	ClientSecret string
+
+	// PKCE enables PKCE (RFC 7636) for the authorization code exchange
+	PKCE bool
+
+	// Groups enables fetching of the user's organizations and teams, read:org scope is requested for that
+	Groups bool
+
+	// AuthURL, TokenURL and APIURL are the endpoints of GitHub Enterprise Server,
+	// e.g. https://github.example.com/login/oauth/authorize, https://github.example.com/login/oauth/access_token
+	// and https://github.example.com/api/v3/. github.com is used if they are empty.
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+}
+
+// GitHub is the provider to sign in with github.com or GitHub Enterprise Server
+type GitHub struct {
+	oAuthConf *oauth2.Config
+	source    string
+	apiURL    string
+	pkce      bool
+	groups    bool
+}
+
+// NewGitHub creates GitHub provider
+func NewGitHub(options GitHubOptions) (*GitHub, error) {
+	p := &GitHub{
+		oAuthConf: &oauth2.Config{
+			ClientID:     options.ClientID,
+			ClientSecret: options.ClientSecret,
+			Endpoint:     ghOAuth.Endpoint,
+		},
+		source: models.SourceGitHub,
+		pkce:   options.PKCE,
+		groups: options.Groups,
+	}
+
+	if options.Groups {
+		p.oAuthConf.Scopes = []string{"read:org"}
+	}
+
+	if options.AuthURL == "" && options.TokenURL == "" && options.APIURL == "" {
+		return p, nil
+	}
+
+	if options.AuthURL == "" || options.TokenURL == "" || options.APIURL == "" {
+		return nil, errors.New("auth, token and API URLs of GitHub Enterprise must be set together")
+	}
+
+	u, err := url.Parse(options.AuthURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("GitHub auth URL %q has no host", options.AuthURL)
+	}
+
+	p.oAuthConf.Endpoint = oauth2.Endpoint{AuthURL: options.AuthURL, TokenURL: options.TokenURL}
+	p.apiURL = options.APIURL
+
+	// The users of the enterprise host don't collide with the ones of github.com
+	p.source = models.SourceGitHub + ":" + u.Host
+
+	return p, nil
+}
+
+// Name identifies the provider in the routes
+func (p *GitHub) Name() string {
+	return "github"
+}
+
+// Title is the name of the provider shown to the users
+func (p *GitHub) Title() string {
+	return "GitHub"
+}
+
+// Source is the value stored as models.User.Source
+func (p *GitHub) Source() string {
+	return p.source
+}
+
+// PKCE tells if PKCE should be used
+func (p *GitHub) PKCE() bool {
+	return p.pkce
+}
+
+// Nonce is false since there is no ID token
+func (p *GitHub) Nonce() bool {
+	return false
+}
+
+// AuthCodeURL returns URL of GitHub authorization page
+func (p *GitHub) AuthCodeURL(state string, opts ...oauth2.AuthCodeOption) string {
+	return p.oAuthConf.AuthCodeURL(state, append(opts, oauth2.AccessTypeOnline)...)
+}
+
+// Identity exchanges the authorization code and returns GitHub user
+func (p *GitHub) Identity(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (*Identity, error) {
+	token, err := p.oAuthConf.Exchange(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := p.oAuthConf.Client(ctx, token)
+
+	githubClient := ghClient.NewClient(httpClient)
+	if p.apiURL != "" {
+		githubClient, err = ghClient.NewEnterpriseClient(p.apiURL, p.apiURL, httpClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	user, _, err := githubClient.Users.Get(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Login == nil {
+		return nil, errors.New("GitHub user has no login")
+	}
+
+	identity := &Identity{Login: *user.Login}
+	if p.groups {
+		if identity.Groups, err = groups(ctx, githubClient); err != nil {
+			return nil, fmt.Errorf("couldn't get organizations and teams of GitHub user: %v", err)
+		}
+	}
+
+	return identity, nil
+}
+
+// groups returns the organizations and the teams of the authenticated user
+func groups(ctx context.Context, githubClient *ghClient.Client) ([]string, error) {
+	var groups []string
+
+	opts := &ghClient.ListOptions{PerPage: 100}
+	for {
+		orgs, resp, err := githubClient.Organizations.List(ctx, "", opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range orgs {
+			groups = append(groups, org.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	opts = &ghClient.ListOptions{PerPage: 100}
+	for {
+		teams, resp, err := githubClient.Organizations.ListUserTeams(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			groups = append(groups, team.GetOrganization().GetLogin()+"/"+team.GetSlug())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,131 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+
+	"github.com/k8s-community/ui/models"
+)
+
+// GitLabURL is the URL of gitlab.com
+const GitLabURL = "https://gitlab.com"
+
+// GitLab is the provider to sign in with gitlab.com or a self-hosted GitLab
+type GitLab struct {
+	baseURL   string
+	source    string
+	oAuthConf *oauth2.Config
+	pkce      bool
+}
+
+// NewGitLab creates GitLab provider:
+// - baseURL is the URL of GitLab instance, e.g. https://gitlab.com
+// - clientID and clientSecret are the parameters of the application from GitLab settings
+// - redirectURL is the callback URL registered in the application (/oauth/gitlab-cb of the service)
+// - pkce enables PKCE (RFC 7636) for the authorization code exchange
+func NewGitLab(baseURL, clientID, clientSecret, redirectURL string, pkce bool) (*GitLab, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("GitLab URL %q has no host", baseURL)
+	}
+
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	// The users of self-hosted instances don't collide with the ones of gitlab.com
+	source := models.SourceGitLab
+	if baseURL != GitLabURL {
+		source = models.SourceGitLab + ":" + u.Host
+	}
+
+	return &GitLab{
+		baseURL: baseURL,
+		source:  source,
+		oAuthConf: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       []string{"read_user"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  baseURL + "/oauth/authorize",
+				TokenURL: baseURL + "/oauth/token",
+			},
+		},
+		pkce: pkce,
+	}, nil
+}
+
+// Name identifies the provider in the routes
+func (p *GitLab) Name() string {
+	return "gitlab"
+}
+
+// Title is the name of the provider shown to the users
+func (p *GitLab) Title() string {
+	return "GitLab"
+}
+
+// Source is the value stored as models.User.Source
+func (p *GitLab) Source() string {
+	return p.source
+}
+
+// PKCE tells if PKCE should be used
+func (p *GitLab) PKCE() bool {
+	return p.pkce
+}
+
+// Nonce is false since there is no ID token
+func (p *GitLab) Nonce() bool {
+	return false
+}
+
+// AuthCodeURL returns URL of GitLab authorization page
+func (p *GitLab) AuthCodeURL(state string, opts ...oauth2.AuthCodeOption) string {
+	return p.oAuthConf.AuthCodeURL(state, opts...)
+}
+
+// Identity exchanges the authorization code and returns GitLab user
+func (p *GitLab) Identity(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (*Identity, error) {
+	token, err := p.oAuthConf.Exchange(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+"/api/v4/user", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.oAuthConf.Client(ctx, token).Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("couldn't get GitLab user: %s", resp.Status)
+	}
+
+	var user struct {
+		Username string `json:"username"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, err
+	}
+
+	if user.Username == "" {
+		return nil, errors.New("GitLab user has no username")
+	}
+
+	return &Identity{Login: user.Username}, nil
+}
@@ -0,0 +1,371 @@
+package providers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/k8s-community/ui/models"
+)
+
+// clockSkew is the allowed difference between the clocks of the service and the provider
+const clockSkew = time.Minute
+
+// keysRefreshInterval is the minimum time between the requests of JWKS,
+// so the tokens with unknown key IDs don't make the service flood the provider
+const keysRefreshInterval = time.Minute
+
+// keysRetryInterval is the time between the requests of JWKS after a failed one
+const keysRetryInterval = 5 * time.Second
+
+// OIDCOptions contains settings of OpenID Connect provider
+type OIDCOptions struct {
+	// Title is the name of the provider shown to the users, e.g. Keycloak
+	Title string
+
+	// Issuer is the URL of the provider, the configuration is discovered at <Issuer>/.well-known/openid-configuration
+	Issuer string
+
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	// Scopes are requested in addition to openid
+	Scopes []string
+
+	// Claim of ID token used as the user's login, e.g. preferred_username
+	Claim string
+
+	PKCE bool
+
+	// HTTPClient is used to reach the provider, http.DefaultClient is used if it's nil
+	HTTPClient *http.Client
+}
+
+// OIDC is the provider to sign in with OpenID Connect identity provider (Keycloak, Dex, Azure AD, etc.)
+type OIDC struct {
+	options   OIDCOptions
+	source    string
+	jwksURL   string
+	oAuthConf *oauth2.Config
+
+	keysMux  sync.RWMutex
+	keys     map[string]*rsa.PublicKey
+	keysNext time.Time // JWKS isn't requested again before this time
+}
+
+// discovery is the part of OpenID Provider Metadata used by the service
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewOIDC creates OpenID Connect provider, the endpoints of the provider are discovered
+func NewOIDC(ctx context.Context, options OIDCOptions) (*OIDC, error) {
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+
+	issuer := strings.TrimSuffix(options.Issuer, "/")
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta discovery
+	if err = getJSON(ctx, options.HTTPClient, issuer+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, fmt.Errorf("couldn't discover OpenID configuration: %v", err)
+	}
+
+	if strings.TrimSuffix(meta.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("issuer %q of OpenID configuration doesn't match %q", meta.Issuer, options.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("OpenID configuration has no authorization, token or JWKS endpoint")
+	}
+
+	options.Issuer = meta.Issuer
+
+	return &OIDC{
+		options: options,
+		source:  models.SourceOIDC + ":" + u.Host + strings.TrimSuffix(u.Path, "/"),
+		jwksURL: meta.JWKSURI,
+		oAuthConf: &oauth2.Config{
+			ClientID:     options.ClientID,
+			ClientSecret: options.ClientSecret,
+			RedirectURL:  options.RedirectURL,
+			Scopes:       append([]string{"openid"}, options.Scopes...),
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  meta.AuthorizationEndpoint,
+				TokenURL: meta.TokenEndpoint,
+			},
+		},
+		keys: make(map[string]*rsa.PublicKey),
+	}, nil
+}
+
+// Name identifies the provider in the routes
+func (p *OIDC) Name() string {
+	return "oidc"
+}
+
+// Title is the name of the provider shown to the users
+func (p *OIDC) Title() string {
+	return p.options.Title
+}
+
+// Source is the value stored as models.User.Source, it contains the issuer
+// so the users of different providers don't collide
+func (p *OIDC) Source() string {
+	return p.source
+}
+
+// PKCE tells if PKCE should be used
+func (p *OIDC) PKCE() bool {
+	return p.options.PKCE
+}
+
+// Nonce is always true, the nonce binds ID token to the login attempt
+func (p *OIDC) Nonce() bool {
+	return true
+}
+
+// AuthCodeURL returns URL of the provider's authorization page
+func (p *OIDC) AuthCodeURL(state string, opts ...oauth2.AuthCodeOption) string {
+	return p.oAuthConf.AuthCodeURL(state, opts...)
+}
+
+// Identity exchanges the authorization code, verifies ID token and returns the user
+// with the login taken from the configured claim and the groups taken from the groups claim.
+// ID token must contain the nonce of the context (see WithNonce).
+func (p *OIDC) Identity(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (*Identity, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.options.HTTPClient)
+
+	token, err := p.oAuthConf.Exchange(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response has no ID token")
+	}
+
+	claims, err := p.verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %v", err)
+	}
+
+	login, ok := claims[p.options.Claim].(string)
+	if !ok || login == "" {
+		return nil, fmt.Errorf("ID token has no claim %s", p.options.Claim)
+	}
+
+	identity := &Identity{Login: login}
+	if groups, ok := claims["groups"].([]interface{}); ok {
+		for _, group := range groups {
+			if group, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, group)
+			}
+		}
+	}
+
+	return identity, nil
+}
+
+// verify checks the signature, the standard claims and the nonce of ID token and returns its claims
+func (p *OIDC) verify(ctx context.Context, rawIDToken string) (map[string]interface{}, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	var hash crypto.Hash
+	switch header.Alg {
+	case "RS256":
+		hash = crypto.SHA256
+	case "RS384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", header.Alg)
+	}
+
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := hash.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(key, hash, hasher.Sum(nil), signature); err != nil {
+		return nil, errors.New("wrong signature")
+	}
+
+	var claims map[string]interface{}
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if claims["iss"] != p.options.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %v", claims["iss"])
+	}
+
+	if !audience(claims["aud"], p.options.ClientID) {
+		return nil, fmt.Errorf("unexpected audience %v", claims["aud"])
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok || time.Now().Add(-clockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, errors.New("token is expired")
+	}
+
+	expected := nonce(ctx)
+	actual, _ := claims["nonce"].(string)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) != 1 {
+		return nil, errors.New("nonce doesn't match")
+	}
+
+	return claims, nil
+}
+
+// key returns the public key specified by its ID, the keys are fetched again
+// if there is no such a key (the provider rotated the keys), but not more often than keysRefreshInterval
+// or keysRetryInterval after a failure. The malformed keys of JWKS are skipped.
+func (p *OIDC) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.keysMux.RLock()
+	key, ok := p.keys[kid]
+	p.keysMux.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	p.keysMux.Lock()
+	if time.Now().Before(p.keysNext) {
+		p.keysMux.Unlock()
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	// The other requests don't fetch JWKS at the same time, they wait for the retry if this one fails
+	p.keysNext = time.Now().Add(keysRetryInterval)
+	p.keysMux.Unlock()
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := getJSON(ctx, p.options.HTTPClient, p.jwksURL, &set); err != nil {
+		return nil, fmt.Errorf("couldn't fetch JWKS: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil || len(n) == 0 {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS doesn't contain RSA signing keys")
+	}
+
+	p.keysMux.Lock()
+	p.keys = keys
+	p.keysNext = time.Now().Add(keysRefreshInterval)
+	p.keysMux.Unlock()
+
+	if key, ok = keys[kid]; !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	return key, nil
+}
+
+// audience tells if the aud claim (a string or an array) contains the client ID
+func audience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// decodeSegment decodes base64 encoded JSON segment of JWT
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// getJSON gets the document and decodes it
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,273 @@
+package providers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testIdP is OpenID Connect provider issuing the ID token set by the test
+type testIdP struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	kid      string
+	idToken  string
+	jwksHits int
+	jwksDown bool // JWKS request fails
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &testIdP{key: key, kid: "key-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discovery{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/auth",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksHits++
+		if idp.jwksDown {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				// The malformed key doesn't make the others unusable
+				"kid": "malformed",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   "not base64!",
+				"e":   "AQAB",
+			}, {
+				"kid": idp.kid,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"id_token":     idp.idToken,
+		})
+	})
+	idp.server = httptest.NewServer(mux)
+
+	return idp
+}
+
+// sign returns ID token with the claims signed by the key
+func (idp *testIdP) sign(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": kid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// claims returns the valid claims of ID token
+func (idp *testIdP) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":                idp.server.URL,
+		"aud":                "ui",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"nonce":              "nonce-1",
+		"preferred_username": "alice",
+		"groups":             []string{"workshop"},
+	}
+}
+
+func newTestOIDC(t *testing.T, idp *testIdP) *OIDC {
+	p, err := NewOIDC(context.Background(), OIDCOptions{
+		Title:        "Test",
+		Issuer:       idp.server.URL + "/",
+		ClientID:     "ui",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/oauth/oidc-cb",
+		Claim:        "preferred_username",
+	})
+	if err != nil {
+		t.Fatalf("NewOIDC() = %v", err)
+	}
+
+	return p
+}
+
+func TestNewOIDC(t *testing.T) {
+	idp := newTestIdP(t)
+	defer idp.server.Close()
+
+	p := newTestOIDC(t, idp)
+	if p.jwksURL != idp.server.URL+"/jwks" {
+		t.Errorf("JWKS URL = %s, want %s", p.jwksURL, idp.server.URL+"/jwks")
+	}
+	if p.oAuthConf.Endpoint.TokenURL != idp.server.URL+"/token" {
+		t.Errorf("token URL = %s, want %s", p.oAuthConf.Endpoint.TokenURL, idp.server.URL+"/token")
+	}
+
+	if _, err := NewOIDC(context.Background(), OIDCOptions{Issuer: idp.server.URL + "/other"}); err == nil {
+		t.Error("NewOIDC() with wrong issuer succeeded, want error")
+	}
+}
+
+func TestOIDCIdentity(t *testing.T) {
+	idp := newTestIdP(t)
+	defer idp.server.Close()
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		key    *rsa.PrivateKey
+		modify func(claims map[string]interface{})
+		nonce  string
+		valid  bool
+	}{
+		{name: "valid", valid: true},
+		{name: "audience in array", modify: func(c map[string]interface{}) { c["aud"] = []string{"other", "ui"} }, valid: true},
+		{name: "wrong signature", key: otherKey},
+		{name: "wrong issuer", modify: func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }},
+		{name: "wrong audience", modify: func(c map[string]interface{}) { c["aud"] = "other" }},
+		{name: "expired", modify: func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "no expiration", modify: func(c map[string]interface{}) { delete(c, "exp") }},
+		{name: "wrong nonce", nonce: "nonce-2"},
+		{name: "no nonce", modify: func(c map[string]interface{}) { delete(c, "nonce") }},
+		{name: "no login", modify: func(c map[string]interface{}) { delete(c, "preferred_username") }},
+	}
+
+	for _, test := range tests {
+		p := newTestOIDC(t, idp)
+
+		key := idp.key
+		if test.key != nil {
+			key = test.key
+		}
+		claims := idp.claims()
+		if test.modify != nil {
+			test.modify(claims)
+		}
+		nonce := "nonce-1"
+		if test.nonce != "" {
+			nonce = test.nonce
+		}
+		idp.idToken = idp.sign(t, key, idp.kid, claims)
+
+		identity, err := p.Identity(WithNonce(context.Background(), nonce), "code")
+		if (err == nil) != test.valid {
+			t.Errorf("%s: Identity() error = %v, want valid %v", test.name, err, test.valid)
+			continue
+		}
+		if !test.valid {
+			continue
+		}
+		if identity.Login != "alice" || len(identity.Groups) != 1 || identity.Groups[0] != "workshop" {
+			t.Errorf("%s: Identity() = %+v, want alice in workshop", test.name, identity)
+		}
+	}
+}
+
+func TestOIDCKeysRefresh(t *testing.T) {
+	idp := newTestIdP(t)
+	defer idp.server.Close()
+
+	p := newTestOIDC(t, idp)
+	ctx := WithNonce(context.Background(), "nonce-1")
+
+	idp.idToken = idp.sign(t, idp.key, idp.kid, idp.claims())
+	if _, err := p.Identity(ctx, "code"); err != nil {
+		t.Fatalf("Identity() = %v", err)
+	}
+
+	// The tokens with unknown key IDs don't make the service fetch JWKS again
+	idp.idToken = idp.sign(t, idp.key, "unknown", idp.claims())
+	for i := 0; i < 3; i++ {
+		if _, err := p.Identity(ctx, "code"); err == nil {
+			t.Fatal("Identity() with unknown key succeeded, want error")
+		}
+	}
+	if idp.jwksHits != 1 {
+		t.Errorf("JWKS was fetched %d times, want 1", idp.jwksHits)
+	}
+
+	// The rotated key is fetched once the refresh interval has passed
+	idp.kid = "key-2"
+	idp.idToken = idp.sign(t, idp.key, idp.kid, idp.claims())
+	p.keysMux.Lock()
+	p.keysNext = time.Now()
+	p.keysMux.Unlock()
+	if _, err := p.Identity(ctx, "code"); err != nil {
+		t.Errorf("Identity() with rotated key = %v", err)
+	}
+	if idp.jwksHits != 2 {
+		t.Errorf("JWKS was fetched %d times, want 2", idp.jwksHits)
+	}
+}
+
+func TestOIDCKeysRetry(t *testing.T) {
+	idp := newTestIdP(t)
+	defer idp.server.Close()
+
+	p := newTestOIDC(t, idp)
+	ctx := WithNonce(context.Background(), "nonce-1")
+	idp.idToken = idp.sign(t, idp.key, idp.kid, idp.claims())
+
+	idp.jwksDown = true
+	if _, err := p.Identity(ctx, "code"); err == nil {
+		t.Fatal("Identity() with unavailable JWKS succeeded, want error")
+	}
+
+	// The failed request is retried soon, not after the refresh interval
+	p.keysMux.RLock()
+	next := p.keysNext
+	p.keysMux.RUnlock()
+	if wait := time.Until(next); wait <= 0 || wait > keysRetryInterval {
+		t.Errorf("JWKS is requested again in %v, want in %v", wait, keysRetryInterval)
+	}
+
+	idp.jwksDown = false
+	p.keysMux.Lock()
+	p.keysNext = time.Now()
+	p.keysMux.Unlock()
+	if _, err := p.Identity(ctx, "code"); err != nil {
+		t.Errorf("Identity() after JWKS is available again = %v", err)
+	}
+	if idp.jwksHits != 2 {
+		t.Errorf("JWKS was fetched %d times, want 2", idp.jwksHits)
+	}
+}
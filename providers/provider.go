@@ -0,0 +1,56 @@
+// Package providers contains identity providers the users sign in with
+package providers
+
+import (
+	"context"
+
+	"golang.org/x/oauth2"
+)
+
+// Provider is an identity provider based on OAuth 2.0 authorization code flow
+type Provider interface {
+	// Name identifies the provider in the routes: /oauth/<name> and /oauth/<name>-cb
+	Name() string
+
+	// Title is the name of the provider shown to the users
+	Title() string
+
+	// Source is the value stored as models.User.Source for the users of the provider
+	Source() string
+
+	// PKCE tells if PKCE should be used for the authorization code exchange
+	PKCE() bool
+
+	// Nonce tells if the nonce should be sent with the authorization request, Identity verifies
+	// that ID token contains the nonce of the context (see WithNonce)
+	Nonce() bool
+
+	// AuthCodeURL returns URL of the provider's authorization page
+	AuthCodeURL(state string, opts ...oauth2.AuthCodeOption) string
+
+	// Identity exchanges the authorization code and returns the user authorized by the provider
+	Identity(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (*Identity, error)
+}
+
+// nonceKey is the key of the nonce in the context
+type nonceKey struct{}
+
+// WithNonce returns the context with the nonce sent with the authorization request
+func WithNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, nonceKey{}, nonce)
+}
+
+// nonce returns the nonce of the context or an empty string
+func nonce(ctx context.Context) string {
+	n, _ := ctx.Value(nonceKey{}).(string)
+	return n
+}
+
+// Identity is the user authorized by the provider
+type Identity struct {
+	Login string
+
+	// Groups are the groups the user is a member of, if the provider supports them and they were requested.
+	// GitHub organizations are represented as "org" and teams as "org/team".
+	Groups []string
+}
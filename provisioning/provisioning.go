@@ -0,0 +1,316 @@
+// Package provisioning keeps the jobs to create users' environments in Kubernetes in DB
+// and runs them by a pool of workers with retries, so the jobs survive restarts of the service.
+package provisioning
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	umClient "github.com/k8s-community/user-manager/client"
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/models"
+)
+
+// lease is the time the running job is owned by the worker,
+// after that the job is considered abandoned (e.g. the pod was killed) and is run again
+const lease = 5 * time.Minute
+
+// errNameTaken fails the job at once: the user's Kubernetes name belongs to another user,
+// so the user can't get the namespace and the credentials of the other one
+var errNameTaken = errors.New("Kubernetes name belongs to another user")
+
+// Options defines the worker pool and the retry policy
+type Options struct {
+	// Workers is the number of jobs run concurrently
+	Workers int
+
+	// PollInterval defines how often the workers look for jobs to run
+	PollInterval time.Duration
+
+	// MaxAttempts is the number of attempts after which the job is failed
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff limit the exponential delay between attempts
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Queue enqueues provisioning jobs and runs them
+type Queue struct {
+	db            *reform.DB
+	logger        logrus.FieldLogger
+	usermanClient *umClient.Client
+	options       Options
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped sync.WaitGroup
+	close   sync.Once
+}
+
+// New creates the queue and starts its workers
+func New(db *reform.DB, logger logrus.FieldLogger, usermanClient *umClient.Client, options Options) *Queue {
+	q := &Queue{
+		db:            db,
+		logger:        logger.WithField("component", "provisioning"),
+		usermanClient: usermanClient,
+		options:       options,
+		wake:          make(chan struct{}, options.Workers),
+		done:          make(chan struct{}),
+	}
+
+	for i := 0; i < options.Workers; i++ {
+		q.stopped.Add(1)
+		go q.work()
+	}
+
+	return q
+}
+
+// Enqueue queues the job for the user specified by source and login to run it immediately.
+// The job which is running already is kept as is.
+func (q *Queue) Enqueue(source, login string) error {
+	err := q.db.InTransaction(func(tx *reform.TX) error {
+		st, err := tx.SelectOneFrom(models.UserTable, "WHERE source = $1 AND name = $2", source, login)
+		if err != nil {
+			return err
+		}
+		user := st.(*models.User)
+
+		now := time.Now().UTC().Truncate(time.Second)
+		job := &models.ProvisioningJob{UserID: user.ID}
+
+		st, err = tx.SelectOneFrom(models.ProvisioningJobTable, "WHERE user_id = $1 FOR UPDATE", user.ID)
+		if err != nil && err != reform.ErrNoRows {
+			return err
+		} else if err != reform.ErrNoRows {
+			job = st.(*models.ProvisioningJob)
+			if job.State == models.JobRunning {
+				return nil
+			}
+		}
+
+		// The finished job starts over, the one waiting for retry is just run earlier
+		if job.State != models.JobQueued {
+			job.State = models.JobQueued
+			job.Attempts = 0
+			job.LastError = nil
+		}
+		job.RunAt = now
+
+		return tx.Save(job)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Don't wait for the next poll
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Close stops the workers and waits for the running jobs until ctx is done.
+// The jobs which aren't finished are run again after their lease expires.
+func (q *Queue) Close(ctx context.Context) error {
+	q.close.Do(func() {
+		close(q.done)
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		q.stopped.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work runs the jobs until the queue is closed
+func (q *Queue) work() {
+	defer q.stopped.Done()
+
+	ticker := time.NewTicker(q.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Run the jobs while there are ones ready
+		for {
+			select {
+			case <-q.done:
+				return
+			default:
+			}
+
+			job, err := q.claim()
+			if err != nil {
+				q.logger.Errorf("Couldn't claim provisioning job: %+v", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+
+			q.run(job)
+		}
+
+		select {
+		case <-q.done:
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim takes the job ready to run, the other workers (including the ones of the other replicas) skip it.
+// nil is returned if there are no jobs ready.
+func (q *Queue) claim() (*models.ProvisioningJob, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var id int64
+	err := q.db.QueryRow(`
+		UPDATE provisioning_jobs SET state = $1, attempts = attempts + 1, run_at = $2, updated_at = $3
+		WHERE id = (
+			SELECT id FROM provisioning_jobs
+			WHERE state IN ($4, $1) AND run_at <= $3
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		models.JobRunning, now.Add(lease), now, models.JobQueued,
+	).Scan(&id)
+	if err == reform.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := q.db.FindByPrimaryKeyFrom(models.ProvisioningJobTable, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return st.(*models.ProvisioningJob), nil
+}
+
+// run syncs the user with user-manager and saves the result of the job
+func (q *Queue) run(job *models.ProvisioningJob) {
+	logger := q.logger.WithFields(logrus.Fields{"job": job.ID, "attempt": job.Attempts})
+
+	st, err := q.db.FindByPrimaryKeyFrom(models.UserTable, job.UserID)
+	if err != nil {
+		logger.Errorf("Couldn't get user %d of provisioning job: %+v", job.UserID, err)
+		q.retry(logger, job, err)
+		return
+	}
+
+	user := st.(*models.User)
+	logger = logger.WithField("user", user.Name)
+	logger.Infof("Sync user with user-manager...")
+
+	if err = q.claimName(user); err != nil {
+		logger.Errorf("Couldn't claim Kubernetes name %s: %+v", user.KubernetesName(), err)
+		q.retry(logger, job, err)
+		return
+	}
+
+	token, resp, err := q.usermanClient.User.Sync(umClient.NewUser(user.KubernetesName()))
+	if err != nil {
+		logger.Errorf("Error during user Kubernetes sync: %+v", err)
+		q.retry(logger, job, err)
+		return
+	}
+
+	logger.Infof("Status from user-manager service is: %s", resp.Status)
+
+	err = q.db.InTransaction(func(tx *reform.TX) error {
+		if token.Cert != "" && token.Token != "" {
+			user.Token = &token.Token
+			user.Cert = &token.Cert
+			if err := tx.UpdateColumns(user, "token", "ca_crt", "updated_at"); err != nil {
+				return err
+			}
+		}
+
+		job.State = models.JobSucceeded
+		job.LastError = nil
+		return tx.UpdateColumns(job, "state", "last_error", "updated_at")
+	})
+	if err != nil {
+		logger.Errorf("Couldn't save result of provisioning job: %+v", err)
+		q.retry(logger, job, err)
+		return
+	}
+
+	logger.Infof("User was provisioned")
+}
+
+// claimName records that the user's Kubernetes name belongs to the user,
+// errNameTaken is returned if another user has claimed it already
+func (q *Queue) claimName(user *models.User) error {
+	var owner int64
+	err := q.db.QueryRow(
+		`INSERT INTO kubernetes_names (name, user_id) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING user_id`,
+		strings.ToLower(user.KubernetesName()), user.ID,
+	).Scan(&owner)
+	if err != nil {
+		return err
+	}
+	if owner != user.ID {
+		return errNameTaken
+	}
+
+	return nil
+}
+
+// retry queues the job again with exponential backoff or fails it when the attempts are exhausted
+// or the name is taken
+func (q *Queue) retry(logger logrus.FieldLogger, job *models.ProvisioningJob, jobErr error) {
+	lastError := jobErr.Error()
+	job.LastError = &lastError
+
+	if job.Attempts >= q.options.MaxAttempts || jobErr == errNameTaken {
+		job.State = models.JobFailed
+		logger.Warningf("Provisioning job failed after %d attempts", job.Attempts)
+	} else {
+		job.State = models.JobQueued
+		job.RunAt = time.Now().UTC().Truncate(time.Second).Add(q.backoff(job.Attempts))
+		logger.Infof("Provisioning job will be retried at %s", job.RunAt)
+	}
+
+	if err := q.db.UpdateColumns(job, "state", "last_error", "run_at", "updated_at"); err != nil {
+		logger.Errorf("Couldn't save state of provisioning job: %+v", err)
+	}
+}
+
+// backoff returns the delay before the next attempt
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.options.MinBackoff
+	for i := 1; i < attempts && delay < q.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > q.options.MaxBackoff {
+		return q.options.MaxBackoff
+	}
+
+	return delay
+}
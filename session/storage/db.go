@@ -1,11 +1,10 @@
 package storage
 
 import (
-	"sync"
-
 	"encoding/json"
+	"sync"
+	"time"
 
-	"github.com/AlekSi/pointer"
 	"github.com/Sirupsen/logrus"
 	"github.com/icza/session"
 	"github.com/k8s-community/ui/models"
@@ -17,19 +16,42 @@ type SessionAttrs struct {
 	HasError  bool `json:"HasError"`
 }
 
+// Options defines the session lifetime
+type Options struct {
+	// IdleTimeout is the time the session expires after the last access
+	IdleTimeout time.Duration
+
+	// MaxAge is the time the session expires after creation regardless of the accesses
+	MaxAge time.Duration
+
+	// SweepInterval defines how often the expired sessions are deleted
+	SweepInterval time.Duration
+}
+
+// DB is a session store which keeps sessions in sessions table,
+// so one user can have several concurrent sessions
 type DB struct {
-	db     *reform.DB
-	logger logrus.FieldLogger
-	mux    *sync.RWMutex // mutex to synchronize access to sessions
+	db      *reform.DB
+	logger  logrus.FieldLogger
+	options Options
+
+	done    chan struct{}
+	stopped sync.WaitGroup
+	close   sync.Once
 }
 
-func NewDB(db *reform.DB, logger logrus.FieldLogger) *DB {
+// NewDB creates the store and starts the sweeper of expired sessions
+func NewDB(db *reform.DB, logger logrus.FieldLogger, options Options) *DB {
 	s := &DB{
-		db:     db,
-		logger: logger,
-		mux:    &sync.RWMutex{},
+		db:      db,
+		logger:  logger,
+		options: options,
+		done:    make(chan struct{}),
 	}
 
+	s.stopped.Add(1)
+	go s.sweep()
+
 	return s
 }
 
@@ -40,10 +62,7 @@ func (s *DB) Get(id string) session.Session {
 	logger := s.logger.WithField("session_id", id)
 	logger.Infof("Get session")
 
-	s.mux.RLock()
-	defer s.mux.RUnlock()
-
-	st, err := s.db.FindOneFrom(models.UserTable, "session_id", id)
+	st, err := s.db.FindOneFrom(models.SessionTable, "session_id", id)
 	if err == reform.ErrNoRows {
 		logger.Infof("Session is not found")
 		return nil
@@ -54,56 +73,80 @@ func (s *DB) Get(id string) session.Session {
 		return nil
 	}
 
-	user := st.(*models.User)
-	if user.SessionData == nil {
-		logger.Infof("Session data is empty")
+	record := st.(*models.Session)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if !now.Before(s.expiresAt(record)) {
+		logger.Infof("Session is expired")
+		if err = s.db.Delete(record); err != nil {
+			logger.Errorf("Couldn't delete expired session: %+v", err)
+		}
 		return nil
 	}
 
+	record.LastAccessAt = now
+	expiresAt := s.expiresAt(record)
+	record.ExpiresAt = &expiresAt
+	if err = s.db.UpdateColumns(record, "last_access_at", "expires_at"); err != nil {
+		logger.Errorf("Couldn't update session access time: %+v", err)
+	}
+
+	st, err = s.db.FindByPrimaryKeyFrom(models.UserTable, record.UserID)
+	if err != nil {
+		logger.Errorf("Couldn't get user %d of session from DB: %+v", record.UserID, err)
+		return nil
+	}
+
+	user := st.(*models.User)
+
 	var data SessionAttrs
-	err = json.Unmarshal([]byte(*user.SessionData), &data)
+	err = json.Unmarshal([]byte(record.Data), &data)
 	if err != nil {
-		logger.Errorf("Couldn't unmarshal session %+v: %+v", user.SessionData, err)
+		logger.Errorf("Couldn't unmarshal session %+v: %+v", record.Data, err)
 		return nil
 	}
 
-	sessionData := session.NewSessionOptions(&session.SessOptions{
-		CAttrs: map[string]interface{}{"Login": user.Name},
-		Attrs:  map[string]interface{}{"Activated": data.Activated, "HasError": data.HasError},
-	})
+	// The provisioning job defines if the user's environment is ready,
+	// the attributes stored in the session are kept for the users without jobs
+	st, err = s.db.SelectOneFrom(models.ProvisioningJobTable, "WHERE user_id = $1", user.ID)
+	if err != nil && err != reform.ErrNoRows {
+		logger.Errorf("Couldn't get provisioning job of user %d from DB: %+v", user.ID, err)
+	} else if err != reform.ErrNoRows {
+		job := st.(*models.ProvisioningJob)
+		data.Activated = job.State == models.JobSucceeded
+		data.HasError = job.State == models.JobFailed
+	}
+
+	sessionData := &dbSession{
+		id:       record.SessionID,
+		created:  record.CreatedAt,
+		accessed: record.LastAccessAt,
+		timeout:  s.options.IdleTimeout,
+		cattrs: map[string]interface{}{
+			"Login":     user.Name,
+			"Source":    user.Source,
+			"UserAgent": value(record.UserAgent),
+			"IP":        value(record.IP),
+		},
+		attrs: map[string]interface{}{"Activated": data.Activated, "HasError": data.HasError},
+		mux:   &sync.RWMutex{},
+	}
 
 	logger.Info("Session was found")
 
 	return sessionData
 }
 
-// Add adds a new session to the store.
+// Add adds a new session to the store or updates the existing one.
+// The user the session belongs to is created if it doesn't exist yet.
 func (s *DB) Add(sess session.Session) {
-	s.mux.RLock()
-	defer s.mux.RUnlock()
-
 	sessID := sess.ID()
 	logger := s.logger.WithField("session_id", sessID)
 	logger.Infof("Add session...")
 
-	user := &models.User{}
-
 	login := sess.CAttr("Login").(string)
 	source := sess.CAttr("Source").(string)
 
-	st, err := s.db.SelectOneFrom(models.UserTable, "WHERE source = $1 AND name = $2", source, login)
-
-	if err != nil && err != reform.ErrNoRows {
-		logger.Errorf("Couldn't get user data (%s) from DB: %+v", login, err)
-		return
-	} else if err != reform.ErrNoRows {
-		user = st.(*models.User)
-	}
-
-	user.Source = source
-	user.Name = login
-	user.SessionID = pointer.ToString(sessID)
-
 	data := &SessionAttrs{
 		Activated: sess.Attr("Activated").(bool),
 		HasError:  sess.Attr("HasError").(bool),
@@ -115,51 +158,129 @@ func (s *DB) Add(sess session.Session) {
 		return
 	}
 
-	user.SessionData = pointer.ToString(string(jsData))
-
-	if token, ok := sess.Attr("Token").(string); ok {
-		user.Token = &token
-	}
-	if cert, ok := sess.Attr("Cert").(string); ok {
-		user.Cert = &cert
-	}
+	err = s.db.InTransaction(func(tx *reform.TX) error {
+		user := &models.User{}
+
+		st, err := tx.SelectOneFrom(models.UserTable, "WHERE source = $1 AND name = $2", source, login)
+		if err != nil && err != reform.ErrNoRows {
+			return err
+		} else if err != reform.ErrNoRows {
+			user = st.(*models.User)
+		}
+
+		// Kubernetes token and cert of the user are saved by the provisioning job
+		if user.ID == 0 {
+			user.Source = source
+			user.Name = login
+			if err = tx.Insert(user); err != nil {
+				return err
+			}
+		}
+
+		record := &models.Session{
+			SessionID: sessID,
+			UserID:    user.ID,
+		}
+
+		st, err = tx.FindOneFrom(models.SessionTable, "session_id", sessID)
+		if err != nil && err != reform.ErrNoRows {
+			return err
+		} else if err != reform.ErrNoRows {
+			record = st.(*models.Session)
+		} else {
+			if userAgent, ok := sess.CAttr("UserAgent").(string); ok && userAgent != "" {
+				record.UserAgent = &userAgent
+			}
+			if ip, ok := sess.CAttr("IP").(string); ok && ip != "" {
+				record.IP = &ip
+			}
+
+			now := time.Now().UTC().Truncate(time.Second)
+			record.CreatedAt = now
+			record.LastAccessAt = now
+			expiresAt := s.expiresAt(record)
+			record.ExpiresAt = &expiresAt
+		}
+
+		record.Data = string(jsData)
+
+		return tx.Save(record)
+	})
 
-	err = s.db.Save(user)
 	if err != nil {
-		logger.Errorf("Couldn't save session in database %+v: %+v", user, err)
+		logger.Errorf("Couldn't save session of user %s in database: %+v", login, err)
 	} else {
 		logger.Info("Session data was saved")
 	}
 }
 
 // Remove removes a session from the store.
+// The other sessions of the same user are kept.
 func (s *DB) Remove(sess session.Session) {
 	s.logger.Infof("Remove session %s", sess.ID())
 
-	s.mux.RLock()
-	defer s.mux.RUnlock()
-
-	st, err := s.db.FindOneFrom(models.UserTable, "session_id", sess.ID())
-	if err == reform.ErrNoRows {
-		return
-	}
-
+	_, err := s.db.DeleteFrom(models.SessionTable, "WHERE session_id = $1", sess.ID())
 	if err != nil {
-		s.logger.Errorf("Couldn't get session %s from DB: %+v", sess.ID(), err)
-		return
+		s.logger.Errorf("Couldn't remove session %s from database: %+v", sess.ID(), err)
 	}
+}
 
-	user := st.(*models.User)
-	user.SessionID = nil
-	user.SessionData = nil
-	err = s.db.Save(user)
-
-	if err != nil {
-		s.logger.Errorf("Couldn't save session in database %+v: %+v", user, err)
+// value returns the string or empty string if it's nil
+func value(s *string) string {
+	if s == nil {
+		return ""
 	}
+	return *s
 }
 
 // Close closes the session store, releasing any resources that were allocated.
+// It stops the sweeper of expired sessions.
 func (s *DB) Close() {
-	return
+	s.close.Do(func() {
+		close(s.done)
+	})
+	s.stopped.Wait()
+}
+
+// expiresAt returns the time the session expires at: after the idle timeout
+// since the last access, but not later than the max age since creation
+func (s *DB) expiresAt(record *models.Session) time.Time {
+	expiresAt := record.LastAccessAt.Add(s.options.IdleTimeout)
+	if absolute := record.CreatedAt.Add(s.options.MaxAge); absolute.Before(expiresAt) {
+		return absolute
+	}
+
+	return expiresAt
+}
+
+// sweep deletes expired sessions periodically until the store is closed
+func (s *DB) sweep() {
+	defer s.stopped.Done()
+
+	ticker := time.NewTicker(s.options.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now().UTC()
+		removed, err := s.db.DeleteFrom(
+			models.SessionTable,
+			// expires_at is NULL for the sessions created before the timeouts were introduced
+			"WHERE expires_at < $1 OR (expires_at IS NULL AND (created_at < $2 OR last_access_at < $3))",
+			now, now.Add(-s.options.MaxAge), now.Add(-s.options.IdleTimeout),
+		)
+		if err != nil {
+			s.logger.Errorf("Couldn't delete expired sessions: %+v", err)
+			continue
+		}
+
+		if removed > 0 {
+			s.logger.Infof("%d expired sessions were deleted", removed)
+		}
+	}
 }
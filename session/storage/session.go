@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// dbSession is a session restored from DB.
+// It keeps the ID of the stored session (session.NewSessionOptions always generates a new one).
+type dbSession struct {
+	id       string
+	created  time.Time
+	accessed time.Time
+	timeout  time.Duration
+	cattrs   map[string]interface{}
+	attrs    map[string]interface{}
+	mux      *sync.RWMutex
+}
+
+// ID returns the id of the session.
+func (s *dbSession) ID() string {
+	return s.id
+}
+
+// New tells if the session is new.
+func (s *dbSession) New() bool {
+	return s.created == s.accessed
+}
+
+// CAttr returns the value of an attribute provided at session creation.
+func (s *dbSession) CAttr(name string) interface{} {
+	return s.cattrs[name]
+}
+
+// Attr returns the value of an attribute stored in the session.
+func (s *dbSession) Attr(name string) interface{} {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	return s.attrs[name]
+}
+
+// SetAttr sets the value of an attribute stored in the session.
+func (s *dbSession) SetAttr(name string, value interface{}) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if value == nil {
+		delete(s.attrs, name)
+	} else {
+		s.attrs[name] = value
+	}
+}
+
+// Attrs returns a copy of all the attribute values stored in the session.
+func (s *dbSession) Attrs() map[string]interface{} {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	attrs := make(map[string]interface{}, len(s.attrs))
+	for k, v := range s.attrs {
+		attrs[k] = v
+	}
+
+	return attrs
+}
+
+// Created returns the session creation time.
+func (s *dbSession) Created() time.Time {
+	return s.created
+}
+
+// Accessed returns the time when the session was last accessed.
+func (s *dbSession) Accessed() time.Time {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	return s.accessed
+}
+
+// Timeout returns the session timeout.
+func (s *dbSession) Timeout() time.Duration {
+	return s.timeout
+}
+
+// Mutex returns the RW mutex of the session.
+func (s *dbSession) Mutex() *sync.RWMutex {
+	return s.mux
+}
+
+// Access registers an access to the session.
+func (s *dbSession) Access() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.accessed = time.Now()
+}
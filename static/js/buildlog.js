@@ -0,0 +1,113 @@
+// Opens the folded section with the line linked by #L<number> and scrolls to the line.
+// The log of the running build is appended with the lines pushed by the server
+// with Server-Sent Events (/builds/<uuid>/log) until the build is finished.
+(function () {
+    var container = document.getElementById('build-log');
+
+    function reveal() {
+        var match = /^#L\d+$/.exec(window.location.hash);
+        if (!match) {
+            return;
+        }
+
+        var line = document.getElementById(window.location.hash.substring(1));
+        if (!line) {
+            return;
+        }
+
+        for (var node = line.parentNode; node; node = node.parentNode) {
+            if (node.tagName === 'DETAILS') {
+                node.open = true;
+            }
+        }
+        line.scrollIntoView();
+    }
+
+    // parent returns the element the line of the section should be appended to
+    function parent(section) {
+        if (!section) {
+            return container;
+        }
+
+        var last = container.lastElementChild;
+        if (last && last.tagName === 'DETAILS' && last.getAttribute('data-section') === section) {
+            return last;
+        }
+
+        var details = document.createElement('details');
+        details.className = 'log-section';
+        details.setAttribute('data-section', section);
+        details.open = true;
+        var summary = document.createElement('summary');
+        summary.textContent = section;
+        details.appendChild(summary);
+        container.appendChild(details);
+
+        return details;
+    }
+
+    // append adds the lines rendered by the server, the unfinished line sent before is replaced
+    function append(lines) {
+        var follow = window.innerHeight + window.pageYOffset >= document.body.scrollHeight - 10;
+
+        lines.forEach(function (line) {
+            var template = document.createElement('template');
+            template.innerHTML = line.html;
+            var element = template.content.firstElementChild;
+
+            var existing = document.getElementById('L' + line.number);
+            if (existing) {
+                existing.parentNode.replaceChild(element, existing);
+            } else {
+                parent(line.section).appendChild(element);
+            }
+        });
+
+        // Keep scrolling with the log if the user is at the bottom of the page
+        if (follow && lines.length) {
+            window.scrollTo(0, document.body.scrollHeight);
+        }
+    }
+
+    // refreshState replaces the state of the build with the one rendered by the server
+    function refreshState() {
+        var xhr = new XMLHttpRequest();
+        xhr.open('GET', window.location.pathname, true);
+        xhr.onload = function () {
+            if (xhr.status !== 200) {
+                return;
+            }
+            var doc = new DOMParser().parseFromString(xhr.responseText, 'text/html');
+            var updated = doc.getElementById('build-state');
+            var state = document.getElementById('build-state');
+            if (updated && state) {
+                state.innerHTML = updated.innerHTML;
+            }
+        };
+        xhr.send();
+    }
+
+    function tail() {
+        if (!container || container.getAttribute('data-final') === 'true' || !window.EventSource) {
+            return;
+        }
+
+        var url = '/builds/' + encodeURIComponent(container.getAttribute('data-uuid')) + '/log' +
+            '?from=' + container.getAttribute('data-from');
+        var source = new EventSource(url);
+
+        source.addEventListener('log', function (e) {
+            append(JSON.parse(e.data).lines);
+        });
+        source.addEventListener('end', function (e) {
+            source.close();
+            append(JSON.parse(e.data).lines);
+            container.setAttribute('data-final', 'true');
+            refreshState();
+        });
+    }
+
+    window.addEventListener('hashchange', reveal);
+    reveal();
+    tail();
+})();
@@ -0,0 +1,69 @@
+// Updates the provisioning status on the home page without reloading it.
+// The changes are pushed by the server with Server-Sent Events (/events),
+// the browsers without EventSource poll the status (/events/status).
+(function () {
+    var container = document.getElementById('provisioning-status');
+    if (!container) {
+        return;
+    }
+
+    var pollInterval = 5000;
+
+    function key(status) {
+        return [status.activated, status.hasError, status.hasCredentials].join(':');
+    }
+
+    function final(status) {
+        return status.hasError || (status.activated && status.hasCredentials);
+    }
+
+    // refresh replaces the status block with the one rendered by the server
+    function refresh() {
+        var xhr = new XMLHttpRequest();
+        xhr.open('GET', '/', true);
+        xhr.onload = function () {
+            if (xhr.status !== 200) {
+                return;
+            }
+            var doc = new DOMParser().parseFromString(xhr.responseText, 'text/html');
+            var updated = doc.getElementById('provisioning-status');
+            if (!updated) {
+                return;
+            }
+            container.innerHTML = updated.innerHTML;
+            container.setAttribute('data-status', updated.getAttribute('data-status'));
+            if (window.componentHandler) {
+                window.componentHandler.upgradeDom();
+            }
+        };
+        xhr.send();
+    }
+
+    function update(status) {
+        if (key(status) !== container.getAttribute('data-status')) {
+            refresh();
+        }
+        return final(status);
+    }
+
+    if (window.EventSource) {
+        var source = new EventSource('/events');
+        source.addEventListener('status', function (e) {
+            if (update(JSON.parse(e.data))) {
+                source.close();
+            }
+        });
+        return;
+    }
+
+    var timer = setInterval(function () {
+        var xhr = new XMLHttpRequest();
+        xhr.open('GET', '/events/status', true);
+        xhr.onload = function () {
+            if (xhr.status === 200 && update(JSON.parse(xhr.responseText))) {
+                clearInterval(timer);
+            }
+        };
+        xhr.send();
+    }, pollInterval);
+})();
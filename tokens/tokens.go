@@ -0,0 +1,165 @@
+// Package tokens manages personal access tokens of the API clients
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/reform.v1"
+
+	"github.com/k8s-community/ui/models"
+)
+
+// Possible scopes of the tokens
+const (
+	ScopeCredentials = "credentials:read" // read Kubernetes credentials
+	ScopeBuilds      = "builds:read"      // read builds and their logs
+)
+
+// Scopes are all the scopes a token may have
+var Scopes = []string{ScopeCredentials, ScopeBuilds}
+
+// prefix makes the tokens recognizable, e.g. for the secret scanners
+const prefix = "k8sc_"
+
+// MaxNameLength is the maximum length of the token name
+const MaxNameLength = 128
+
+// lastUseInterval is the precision of the time of the last use, so each request doesn't update the token
+const lastUseInterval = time.Minute
+
+var (
+	// ErrInvalid is returned for the tokens which don't exist, are expired or revoked
+	ErrInvalid = errors.New("access token is invalid, expired or revoked")
+
+	// ErrNotFound is returned if the user doesn't have such a token
+	ErrNotFound = errors.New("access token not found")
+
+	// ErrInvalidName is returned if the token name is empty or too long
+	ErrInvalidName = fmt.Errorf("token name should be from 1 to %d characters long", MaxNameLength)
+)
+
+// Create creates a new token of the user, the token is returned only once since only its hash is kept.
+// The token expires after ttl, it doesn't expire if ttl is zero.
+// The querier is either DB or a transaction, so the token may be created together with other changes.
+func Create(
+	q *reform.Querier, userID int64, name string, scopes []string, ttl time.Duration,
+) (string, *models.AccessToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > MaxNameLength {
+		return "", nil, ErrInvalidName
+	}
+	for _, scope := range scopes {
+		if !Known(scope) {
+			return "", nil, fmt.Errorf("unknown scope %s", scope)
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	token := prefix + base64.RawURLEncoding.EncodeToString(b)
+
+	accessToken := &models.AccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hash(token),
+		Scopes:    strings.Join(scopes, ","),
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().UTC().Truncate(time.Second).Add(ttl)
+		accessToken.ExpiresAt = &expiresAt
+	}
+	if err := q.Insert(accessToken); err != nil {
+		return "", nil, err
+	}
+
+	return token, accessToken, nil
+}
+
+// List returns the tokens of the user, the newest first
+func List(db *reform.DB, userID int64) ([]*models.AccessToken, error) {
+	structs, err := db.SelectAllFrom(models.AccessTokenTable, "WHERE user_id = $1 ORDER BY created_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]*models.AccessToken, 0, len(structs))
+	for _, st := range structs {
+		tokens = append(tokens, st.(*models.AccessToken))
+	}
+
+	return tokens, nil
+}
+
+// Revoke deletes the token of the user
+func Revoke(db *reform.DB, userID, id int64) error {
+	deleted, err := db.DeleteFrom(models.AccessTokenTable, "WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Authenticate returns the token and its owner, the time of the last use of the token is updated
+func Authenticate(db *reform.DB, token string) (*models.AccessToken, *models.User, error) {
+	if !strings.HasPrefix(token, prefix) {
+		return nil, nil, ErrInvalid
+	}
+
+	st, err := db.FindOneFrom(models.AccessTokenTable, "token_hash", hash(token))
+	if err == reform.ErrNoRows {
+		return nil, nil, ErrInvalid
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	accessToken := st.(*models.AccessToken)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if accessToken.Expired(now) {
+		return nil, nil, ErrInvalid
+	}
+
+	st, err = db.FindByPrimaryKeyFrom(models.UserTable, accessToken.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if accessToken.LastUsedAt == nil || now.Sub(*accessToken.LastUsedAt) >= lastUseInterval {
+		accessToken.LastUsedAt = &now
+		if err = db.UpdateColumns(accessToken, "last_used_at"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return accessToken, st.(*models.User), nil
+}
+
+// Known tells if the scope is one of Scopes
+func Known(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hash returns the hash of the token kept in DB, the tokens are random enough for a fast hash
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
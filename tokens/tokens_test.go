@@ -0,0 +1,120 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k8s-community/ui/db/dbtest"
+	"github.com/k8s-community/ui/models"
+)
+
+func TestAuthenticateWrongPrefix(t *testing.T) {
+	// The token isn't looked up, so the database isn't needed
+	for _, token := range []string{"", "k8sc", "ghp_abcdef", "Bearer k8sc_abcdef"} {
+		if _, _, err := Authenticate(nil, token); err != ErrInvalid {
+			t.Errorf("Authenticate(%q) = %v, want %v", token, err, ErrInvalid)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	db := dbtest.DB(t)
+
+	user := &models.User{Name: dbtest.Login("alice"), Source: models.SourceGitHub}
+	if err := db.Insert(user); err != nil {
+		t.Fatal(err)
+	}
+
+	token, accessToken, err := Create(db.Querier, user.ID, "CI", []string{ScopeBuilds}, 0)
+	if err != nil {
+		t.Fatalf("Create() = %v", err)
+	}
+	if !strings.HasPrefix(token, prefix) || accessToken.ExpiresAt != nil {
+		t.Fatalf("Create() = %s, %v, want token with %s prefix without expiration", token, accessToken, prefix)
+	}
+
+	found, owner, err := Authenticate(db, token)
+	if err != nil {
+		t.Fatalf("Authenticate() = %v", err)
+	}
+	if found.ID != accessToken.ID || owner.ID != user.ID || found.LastUsedAt == nil {
+		t.Errorf("Authenticate() = %v, %v, want the token of %s with the time of the use", found, owner, user.Name)
+	}
+	if found.HasScope(ScopeCredentials) {
+		t.Errorf("token has %s scope, want %s only", ScopeCredentials, ScopeBuilds)
+	}
+
+	// The token of the same length, but with another hash
+	unknown := prefix + strings.Repeat("A", len(token)-len(prefix))
+	if _, _, err = Authenticate(db, unknown); err != ErrInvalid {
+		t.Errorf("Authenticate() of unknown token = %v, want %v", err, ErrInvalid)
+	}
+
+	if err = Revoke(db, user.ID, accessToken.ID); err != nil {
+		t.Fatalf("Revoke() = %v", err)
+	}
+	if _, _, err = Authenticate(db, token); err != ErrInvalid {
+		t.Errorf("Authenticate() of revoked token = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestAuthenticateExpired(t *testing.T) {
+	db := dbtest.DB(t)
+
+	user := &models.User{Name: dbtest.Login("alice"), Source: models.SourceGitHub}
+	if err := db.Insert(user); err != nil {
+		t.Fatal(err)
+	}
+
+	token, accessToken, err := Create(db.Querier, user.ID, "CI", Scopes, time.Hour)
+	if err != nil {
+		t.Fatalf("Create() = %v", err)
+	}
+	if accessToken.ExpiresAt == nil || accessToken.ExpiresAt.Before(time.Now().Add(59*time.Minute)) {
+		t.Fatalf("token expires at %v, want in an hour", accessToken.ExpiresAt)
+	}
+	if _, _, err = Authenticate(db, token); err != nil {
+		t.Fatalf("Authenticate() of token which isn't expired yet = %v", err)
+	}
+
+	expired := time.Now().UTC().Truncate(time.Second).Add(-time.Second)
+	accessToken.ExpiresAt = &expired
+	if err = db.UpdateColumns(accessToken, "expires_at"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = Authenticate(db, token); err != ErrInvalid {
+		t.Errorf("Authenticate() of expired token = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestRevokeOtherUser(t *testing.T) {
+	db := dbtest.DB(t)
+
+	alice := &models.User{Name: dbtest.Login("alice"), Source: models.SourceGitHub}
+	bob := &models.User{Name: dbtest.Login("bob"), Source: models.SourceGitHub}
+	for _, user := range []*models.User{alice, bob} {
+		if err := db.Insert(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	token, accessToken, err := Create(db.Querier, alice.ID, "CI", Scopes, 0)
+	if err != nil {
+		t.Fatalf("Create() = %v", err)
+	}
+
+	if err = Revoke(db, bob.ID, accessToken.ID); err != ErrNotFound {
+		t.Errorf("Revoke() of other user's token = %v, want %v", err, ErrNotFound)
+	}
+	if _, _, err = Authenticate(db, token); err != nil {
+		t.Errorf("Authenticate() of token revoked by other user = %v, want it valid", err)
+	}
+
+	if err = Revoke(db, alice.ID, accessToken.ID); err != nil {
+		t.Errorf("Revoke() = %v", err)
+	}
+	if err = Revoke(db, alice.ID, accessToken.ID); err != ErrNotFound {
+		t.Errorf("Revoke() of revoked token = %v, want %v", err, ErrNotFound)
+	}
+}
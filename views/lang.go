@@ -0,0 +1,70 @@
+package views
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Negotiate returns the supported language preferred by the user according to Accept-Language header,
+// e.g. "ru" for "ru-RU,ru;q=0.9,en;q=0.8". DefaultLang is returned if none of the languages is supported.
+func Negotiate(acceptLanguage string, supported []string) string {
+	type preference struct {
+		lang    string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, item := range strings.Split(acceptLanguage, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ";")
+		lang := strings.ToLower(strings.TrimSpace(parts[0]))
+		if lang == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			preferences = append(preferences, preference{lang: lang, quality: quality})
+		}
+	}
+
+	// The order of the header is kept for the languages of the same quality
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+
+	for _, p := range preferences {
+		if p.lang == "*" {
+			return DefaultLang
+		}
+
+		// Only the primary subtag is taken into account: en-US is en
+		base := strings.SplitN(p.lang, "-", 2)[0]
+		for _, lang := range supported {
+			if lang == base {
+				return lang
+			}
+		}
+	}
+
+	return DefaultLang
+}
+
+// Supported tells if the language is one of the supported ones
+func Supported(lang string, supported []string) bool {
+	for _, l := range supported {
+		if l == lang {
+			return true
+		}
+	}
+
+	return false
+}
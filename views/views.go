@@ -0,0 +1,287 @@
+// Package views keeps the HTML templates of the pages parsed once at startup.
+// The pages are kept in <dir>/<page>.html, each page is combined with <dir>/layout.html.
+// The texts are translated with the message catalogs <dir>/messages/<lang>.yaml
+// which map English texts to the translations, so the missing translations are shown in English.
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultLang is the language of the texts in the templates
+const DefaultLang = "en"
+
+// layout is the name of the template shared by the pages
+const layout = "layout"
+
+// messagesDir is the directory of the message catalogs inside the templates directory
+const messagesDir = "messages"
+
+// Registry keeps the parsed pages and the message catalogs
+type Registry struct {
+	dir string
+	log logrus.FieldLogger
+
+	mux      sync.RWMutex
+	pages    map[string]*template.Template
+	catalogs map[string]map[string]string
+	stamp    string // the state of the files the pages were parsed from
+
+	done  chan struct{}
+	close sync.Once
+}
+
+// New parses all the templates and the catalogs in the directory, an error is returned if any of them is broken
+func New(dir string, log logrus.FieldLogger) (*Registry, error) {
+	r := &Registry{dir: dir, log: log, done: make(chan struct{})}
+
+	stamp, err := r.state()
+	if err != nil {
+		return nil, err
+	}
+	if err = r.load(stamp); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Lookup returns the page which texts are translated into the language
+func (r *Registry) Lookup(lang, name string) (*template.Template, error) {
+	r.mux.RLock()
+	page, ok := r.pages[name]
+	catalog := r.catalogs[lang]
+	r.mux.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("template %s is not found", name)
+	}
+
+	// The parsed pages are never executed, so they can be cloned to bind the language
+	t, err := page.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Funcs(template.FuncMap{
+		"t": func(text string, args ...interface{}) template.HTML {
+			return translate(catalog, text, args...)
+		},
+		"tv": func(value string) string {
+			return translateValue(catalog, value)
+		},
+		"lang": func() string {
+			return lang
+		},
+	}), nil
+}
+
+// Render writes the page in the language with the layout
+func (r *Registry) Render(w io.Writer, lang, name string, data interface{}) error {
+	t, err := r.Lookup(lang, name)
+	if err != nil {
+		return err
+	}
+
+	return t.ExecuteTemplate(w, layout, data)
+}
+
+// Translate returns the text translated into the language, the text is returned as is if it's not translated
+func (r *Registry) Translate(lang, text string) string {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	if translated := r.catalogs[lang][text]; translated != "" {
+		return translated
+	}
+
+	return text
+}
+
+// Langs returns the supported languages, DefaultLang is the first
+func (r *Registry) Langs() []string {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	langs := []string{DefaultLang}
+	for lang := range r.catalogs {
+		if lang != DefaultLang {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs[1:])
+
+	return langs
+}
+
+// Watch checks the templates with the interval and parses them again if they are changed.
+// It's intended for development, the broken templates are reported and the previous ones are kept.
+func (r *Registry) Watch(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-ticker.C:
+			}
+
+			stamp, err := r.state()
+			if err != nil {
+				r.log.Errorf("Couldn't check templates: %+v", err)
+				continue
+			}
+
+			r.mux.RLock()
+			changed := stamp != r.stamp
+			r.mux.RUnlock()
+			if !changed {
+				continue
+			}
+
+			if err = r.load(stamp); err != nil {
+				r.log.Errorf("Couldn't reload templates: %+v", err)
+				r.mux.Lock()
+				// Don't report the same error until the templates are changed again
+				r.stamp = stamp
+				r.mux.Unlock()
+				continue
+			}
+			r.log.Infof("Templates were reloaded")
+		}
+	}()
+}
+
+// Close stops watching the templates
+func (r *Registry) Close() {
+	r.close.Do(func() {
+		close(r.done)
+	})
+}
+
+// load parses the pages and the catalogs and replaces the current ones if all of them are valid
+func (r *Registry) load(stamp string) error {
+	layoutFile := filepath.Join(r.dir, layout+".html")
+	files, err := filepath.Glob(filepath.Join(r.dir, "*.html"))
+	if err != nil {
+		return err
+	}
+
+	// The functions are bound to the language by Lookup
+	funcs := template.FuncMap{
+		"t":    func(text string, args ...interface{}) template.HTML { return translate(nil, text, args...) },
+		"tv":   func(value string) string { return translateValue(nil, value) },
+		"lang": func() string { return DefaultLang },
+	}
+
+	pages := make(map[string]*template.Template)
+	for _, file := range files {
+		if file == layoutFile {
+			continue
+		}
+
+		t, err := template.New(layout).Funcs(funcs).ParseFiles(layoutFile, file)
+		if err != nil {
+			return err
+		}
+		pages[strings.TrimSuffix(filepath.Base(file), ".html")] = t
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("there are no templates in %s", r.dir)
+	}
+
+	catalogFiles, err := filepath.Glob(filepath.Join(r.dir, messagesDir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+
+	catalogs := make(map[string]map[string]string)
+	for _, file := range catalogFiles {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		catalog := make(map[string]string)
+		if err = yaml.Unmarshal(data, &catalog); err != nil {
+			return fmt.Errorf("couldn't parse message catalog %s: %v", file, err)
+		}
+		catalogs[strings.TrimSuffix(filepath.Base(file), ".yaml")] = catalog
+	}
+
+	r.mux.Lock()
+	r.pages = pages
+	r.catalogs = catalogs
+	r.stamp = stamp
+	r.mux.Unlock()
+
+	return nil
+}
+
+// state returns the names, the sizes and the modification times of the template and catalog files
+func (r *Registry) state() (string, error) {
+	var state bytes.Buffer
+	err := filepath.Walk(r.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(path); !info.IsDir() && (ext == ".html" || ext == ".yaml") {
+			fmt.Fprintf(&state, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+		}
+		return nil
+	})
+
+	return state.String(), err
+}
+
+// translate returns the translation of the text with the arguments formatted by the verbs of the text.
+// The catalogs are a part of the service, so the texts may contain HTML, but the arguments are escaped.
+// The text is written as HTML even if it's not translated, so it must be a literal of the template,
+// the values of the data are translated with translateValue.
+func translate(catalog map[string]string, text string, args ...interface{}) template.HTML {
+	if translated := catalog[text]; translated != "" {
+		text = translated
+	}
+
+	if len(args) == 0 {
+		return template.HTML(text)
+	}
+
+	escaped := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch arg.(type) {
+		case int, int64, uint, uint64, float64:
+			// The numbers are kept for the numeric verbs, they don't need escaping
+			escaped[i] = arg
+		default:
+			escaped[i] = template.HTMLEscapeString(fmt.Sprint(arg))
+		}
+	}
+
+	return template.HTML(fmt.Sprintf(text, escaped...))
+}
+
+// translateValue returns the translation of the value of the data, e.g. the status of the build.
+// The value may come from the other services, so it's returned as a string and escaped by the template.
+func translateValue(catalog map[string]string, value string) string {
+	if translated := catalog[value]; translated != "" {
+		return translated
+	}
+
+	return value
+}
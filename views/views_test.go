@@ -0,0 +1,63 @@
+package views
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+)
+
+func TestRenderTranslations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "views")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"layout.html": `{{ define "layout" }}{{ template "content" . }}{{ end }}`,
+		"page.html": `{{ define "content" }}` +
+			`{{ t "Hello, <b>%s</b>! %d builds" .Login .Count }}|{{ tv .Status }}|{{ tv .Remote }}{{ end }}`,
+		"messages/ru.yaml": `"Hello, <b>%s</b>! %d builds": "Привет, <b>%s</b>! Сборок: %d"` + "\n" +
+			`"pending": "выполняется"` + "\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pages, err := New(dir, logrus.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]interface{}{
+		"Login":  "<alice>",
+		"Count":  3,
+		"Status": "pending",
+		"Remote": "<script>alert(1)</script>",
+	}
+
+	tests := map[string]string{
+		"en": "Hello, <b>&lt;alice&gt;</b>! 3 builds|pending|&lt;script&gt;alert(1)&lt;/script&gt;",
+		"ru": "Привет, <b>&lt;alice&gt;</b>! Сборок: 3|выполняется|&lt;script&gt;alert(1)&lt;/script&gt;",
+	}
+
+	for lang, want := range tests {
+		var page bytes.Buffer
+		if err = pages.Render(&page, lang, "page", data); err != nil {
+			t.Fatalf("Render(%s) error: %v", lang, err)
+		}
+		if page.String() != want {
+			t.Errorf("Render(%s) = %q, want %q", lang, page.String(), want)
+		}
+	}
+}